@@ -12,6 +12,7 @@ type BaofuClient struct {
 	AccountService  *services.AccountService  // 账户服务
 	PaymentService  *services.PaymentService  // 支付服务
 	MerchantService *services.MerchantService // 商户报备服务
+	ReconService    *services.ReconService    // 对账文件服务
 }
 
 // NewClient 创建宝付支付客户端
@@ -59,6 +60,7 @@ func NewClient(merchantID, terminalID, privateKey, publicCert, bfPubCert string,
 		AccountService:  services.NewAccountService(cfg),
 		PaymentService:  services.NewPaymentService(cfg),
 		MerchantService: services.NewMerchantService(cfg),
+		ReconService:    services.NewReconService(cfg),
 	}
 
 	// 应用选项
@@ -19,6 +19,39 @@ const (
 	MethodTransfer = "T-1001-013-13"
 	// 账户间转账查询 T-1001-013-10
 	MethodTransferQuery = "T-1001-013-10"
+	// 账户信息修改 T-1001-013-02
+	MethodAccountModify = "T-1001-013-02"
+	// 账户信息修改查询 T-1001-013-04
+	MethodAccountModifyQuery = "T-1001-013-04"
+	// 绑定结算卡 T-1001-013-07
+	MethodBindCard = "T-1001-013-07"
+	// 解绑结算卡 T-1001-013-08
+	MethodUnbindCard = "T-1001-013-08"
+	// 更换结算卡 T-1001-013-09
+	MethodReplaceCard = "T-1001-013-09"
+	// 结算卡查询 T-1001-013-11
+	MethodCardQuery = "T-1001-013-11"
+	// 资质文件上传 T-1001-013-16
+	MethodQualificationUpload = "T-1001-013-16"
+	// 资金冻结 T-1001-013-17
+	MethodFreeze = "T-1001-013-17"
+	// 资金解冻 T-1001-013-18
+	MethodUnfreeze = "T-1001-013-18"
+	// 冻结/解冻查询 T-1001-013-19
+	MethodFreezeQuery = "T-1001-013-19"
+	// 账户明细查询 T-1001-013-20
+	MethodAccountDetailQuery = "T-1001-013-20"
+	// 销户 T-1001-013-21
+	MethodCloseAccount = "T-1001-013-21"
+	// 销户查询 T-1001-013-22
+	MethodCloseAccountQuery = "T-1001-013-22"
+	// 账户充值 T-1001-013-23
+	MethodRecharge = "T-1001-013-23"
+	// 充值查询 T-1001-013-24
+	MethodRechargeQuery = "T-1001-013-24"
+
+	AccountUploadHostTest = "https://vgw.baofoo.com/union-gw/api/{报文编号}/upload.do"
+	AccountUploadHostProd = "https://public.baofu.com/union-gw/api/{报文编号}/upload.do"
 )
 
 // 聚合支付服务
@@ -40,6 +73,14 @@ const (
 	MethodShareQuery = "share_query"
 	// refund_query 退款订单查询
 	MethodRefundQuery = "refund_query"
+	// share_finish 完结分账，将未分账金额解冻给主商户
+	MethodShareFinish = "share_finish"
+	// share_finish_query 完结分账查询
+	MethodShareFinishQuery = "share_finish_query"
+	// share_return 分账回退
+	MethodShareReturn = "share_return"
+	// share_return_query 分账回退查询
+	MethodShareReturnQuery = "share_return_query"
 )
 
 // 聚合报备服务
@@ -53,4 +94,17 @@ const (
 	MethodMerchantReportQuery = "merchant_report_query"
 	// 绑定授权目录 bind_sub_config
 	MethodBindSubConfig = "bind_sub_config"
+	// 查询授权目录 query_sub_config
+	MethodQuerySubConfig = "query_sub_config"
+)
+
+// 对账文件服务
+const (
+	ReconServiceHostTest = "https://vgw.baofoo.com/boas/api/fileLoadNewRequest"
+	ReconServiceHostProd = "https://public.baofu.com/boas/api/fileLoadNewRequest"
+
+	// 聚合支付对账文件
+	ReconFileTypeJuhe = "JUHE"
+	// 账簿对账文件
+	ReconFileTypeAccount = "ACCOUNT"
 )
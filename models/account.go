@@ -1,5 +1,10 @@
 package models
 
+import (
+	"fmt"
+	"io"
+)
+
 // AccountResponse 账户操作的通用响应
 type AccountResponse struct {
 	ReturnCode  string `json:"returnCode"`  // 返回码
@@ -14,19 +19,36 @@ type AccountRequest struct {
 	Body   map[string]interface{} `json:"body"`   // 请求体
 }
 
+// 账户类型
+const (
+	AccTypePersonal = "1" // 个人
+	AccTypeMerchant = "2" // 商户（企业、个体户）
+)
+
 // AccountOpenRequest 开户请求参数
 type AccountOpenRequest struct {
-	NotifyUrl string `json:"notifyUrl"` // 异步通知地址
+	NotifyUrl    string `json:"notifyUrl"`    // 异步通知地址
+	AccType      string `json:"accType"`      // 账户类型 1个人 2商户，默认2
+	Version      string `json:"version"`      // 接口版本号，默认4.1.0
+	BusinessType string `json:"businessType"` // 业务类型，默认BCT2.0（宝财通2.0）
+
+	// 平台信息（代理模式必传）
+	PlatformNo                 string `json:"platformNo"`                 // 平台号(主商户号)
+	PlatformTerminalId         string `json:"platformTerminalId"`         // 平台终端号
+	QualificationTransSerialNo string `json:"qualificationTransSerialNo"` // 资质文件流水号，businessType为宝财通2.0时非必填
+
+	// 个人账户信息
+	Mobile string `json:"mobile"` // 个人手机号 (个人开户必传)
 
 	// 商户账户信息
 	TransSerialNo       string `json:"transSerialNo"`       // 请求流水号
 	LoginNo             string `json:"loginNo"`             // 登录号(用户ID)建议长度8位以上 唯一
 	Email               string `json:"email"`               // 邮箱
 	SelfEmployed        string `json:"selfEmployed"`        // 是否个体户 默认为false
-	CustomerName        string `json:"customerName"`        // 商户名称（营业执照上的名称）
+	CustomerName        string `json:"customerName"`        // 商户名称（营业执照上的名称），个人开户为姓名
 	AliasName           string `json:"aliasName"`           // 商户名称别名  (选传)
 	CertificateNo       string `json:"certificateNo"`       // 证件号码
-	CertificateType     string `json:"certificateType"`     // 证件类型 营业执照:LICENSE
+	CertificateType     string `json:"certificateType"`     // 证件类型 营业执照:LICENSE 身份证:ID
 	CorporateName       string `json:"corporateName"`       // 法人姓名
 	CorporateCertType   string `json:"corporateCertType"`   // 法人证件类型  身份证:ID
 	CorporateCertId     string `json:"corporateCertId"`     // 法人身份证号码
@@ -53,6 +75,116 @@ type OpenAccountQueryRequest struct {
 	AccType         string `json:"accType"`         // 账户类型 1个人，2商户
 }
 
+// 开户状态
+const (
+	OpenAccountStateFail       = 0 // 开户失败
+	OpenAccountStateSuccess    = 1 // 开户成功
+	OpenAccountStateProcessing = 2 // 开户处理中（审核中），结果以异步通知或开户查询为准
+)
+
+// OpenAccountResponse 开户响应
+type OpenAccountResponse struct {
+	Body struct {
+		RetCode       int    `json:"retCode"`       // 返回码 1 成功 0 失败
+		ErrorCode     string `json:"errorCode"`     // 错误码
+		ErrorMsg      string `json:"errorMsg"`      // 错误原因
+		TransSerialNo string `json:"transSerialNo"` // 请求流水号
+		LoginNo       string `json:"loginNo"`       // 登录号
+		ContractNo    string `json:"contractNo"`    // 客户账户号
+		State         int    `json:"state"`         // 开户状态 0失败 1成功 2处理中
+		FailReason    string `json:"failReason"`    // 开户失败原因
+	} `json:"body"`
+	Header AccountRespHeader `json:"header"`
+}
+
+// OpenAccountQueryResponse 开户查询响应
+type OpenAccountQueryResponse struct {
+	Body struct {
+		RetCode      int    `json:"retCode"`      // 返回码 1 成功 0 失败
+		ErrorCode    string `json:"errorCode"`    // 错误码
+		ErrorMsg     string `json:"errorMsg"`     // 错误原因
+		LoginNo      string `json:"loginNo"`      // 登录号
+		ContractNo   string `json:"contractNo"`   // 客户账户号
+		CustomerName string `json:"customerName"` // 开户名称
+		AccType      string `json:"accType"`      // 账户类型 1个人 2商户
+		State        int    `json:"state"`        // 开户状态 0失败 1成功 2处理中
+		FailReason   string `json:"failReason"`   // 开户失败原因
+	} `json:"body"`
+	Header AccountRespHeader `json:"header"`
+}
+
+// AccountError 账户接口业务失败（retCode 为 0）时返回的错误
+type AccountError struct {
+	ServiceTp   string // 服务类型（报文编号）
+	SysRespCode string // 系统返回码
+	ErrorCode   string // 业务错误码
+	ErrorMsg    string // 业务错误原因
+}
+
+func (e *AccountError) Error() string {
+	msg := e.ErrorMsg
+	if msg == "" {
+		msg = e.SysRespCode
+	}
+	return fmt.Sprintf("账户接口 %s 请求失败: [%s] %s", e.ServiceTp, e.ErrorCode, msg)
+}
+
+// NewAccountError 根据响应头和业务返回码生成错误，retCode 为1时返回nil
+func NewAccountError(header AccountRespHeader, retCode int, errorCode, errorMsg string) error {
+	if retCode == 1 {
+		return nil
+	}
+	if errorMsg == "" {
+		errorMsg = header.SysRespDesc
+	}
+	return &AccountError{
+		ServiceTp:   header.ServiceTp,
+		SysRespCode: header.SysRespCode,
+		ErrorCode:   errorCode,
+		ErrorMsg:    errorMsg,
+	}
+}
+
+// QualificationType 资质文件类型
+type QualificationType string
+
+const (
+	QualificationBusinessLicense QualificationType = "BUSINESS_LICENSE" // 营业执照
+	QualificationIDCardFront     QualificationType = "ID_CARD_FRONT"    // 法人身份证人像面
+	QualificationIDCardBack      QualificationType = "ID_CARD_BACK"     // 法人身份证国徽面
+	QualificationBankPermit      QualificationType = "BANK_PERMIT"      // 开户许可证
+)
+
+// QualificationFile 资质文件
+type QualificationFile struct {
+	Type     QualificationType // 文件类型
+	FileName string            // 文件名，扩展名须为 jpg、jpeg、png 或 pdf
+	Reader   io.Reader         // 文件内容
+}
+
+// QualificationUploadRequest 资质文件上传请求参数
+// UploadURL、FileField、FileInfo 的默认值未在现有接口文档中给出，接入时须按宝付提供的上传接口文档核对，不一致时通过这些字段指定
+type QualificationUploadRequest struct {
+	TransSerialNo string              // 请求流水号，为空时自动生成，上传成功后作为开户请求的 qualificationTransSerialNo
+	LoginNo       string              // 开户登录号
+	Files         []QualificationFile // 资质文件
+
+	UploadURL string                                                                         // 上传地址，{报文编号} 替换为报文编号；为空时使用 consts.AccountUploadHostTest/Prod
+	FileField func(index int, f QualificationFile) string                                    // 文件的表单字段名，为空时依次为 file1、file2...
+	FileInfo  func(field string, f QualificationFile, content []byte) map[string]interface{} // 报文中的文件描述，为空时包含 fieldName、fileType、fileName、fileSize、fileDigest(SHA-256)
+}
+
+// QualificationUploadResponse 资质文件上传响应
+type QualificationUploadResponse struct {
+	Body struct {
+		RetCode       int    `json:"retCode"`       // 返回码 1 成功 0 失败
+		ErrorCode     string `json:"errorCode"`     // 错误码
+		ErrorMsg      string `json:"errorMsg"`      // 错误原因
+		TransSerialNo string `json:"transSerialNo"` // 资质文件流水号
+	} `json:"body"`
+	Header AccountRespHeader `json:"header"`
+}
+
 // AccountModifyRequest 修改账户请求参数
 type AccountModifyRequest struct {
 	// 请求头
@@ -63,6 +195,8 @@ type AccountModifyRequest struct {
 
 	// 请求体
 	Version         string `json:"version"`         // 版本号
+	TransSerialNo   string `json:"transSerialNo"`   // 请求流水号，用于修改结果查询
+	NoticeUrl       string `json:"noticeUrl"`       // 异步通知地址（修改结算卡需审核时通知结果）
 	ContractNo      string `json:"contractNo"`      // 合同号（客户账户）
 	AcctType        string `json:"acctType"`        // 账户类型：1个人，2机构
 	AcctName        string `json:"acctName"`        // 账户名称
@@ -79,6 +213,126 @@ type AccountModifyRequest struct {
 	CVV             string `json:"cvv"`             // 安全码
 }
 
+// AccountRespHeader 账户接口响应头
+type AccountRespHeader struct {
+	MemberId    string `json:"memberId"`    // 商户号
+	TerminalId  string `json:"terminalId"`  // 终端号
+	ServiceTp   string `json:"serviceTp"`   // 服务类型
+	SysRespCode string `json:"sysRespCode"` // 返回码
+	SysRespDesc string `json:"sysRespDesc"` // 返回信息
+}
+
+// AccountModifyResponse 修改账户响应
+type AccountModifyResponse struct {
+	Body struct {
+		RetCode       int    `json:"retCode"`       // 返回码 1 成功 0 失败
+		ErrorCode     string `json:"errorCode"`     // 错误码
+		ErrorMsg      string `json:"errorMsg"`      // 错误原因
+		ContractNo    string `json:"contractNo"`    // 客户账户号
+		TransSerialNo string `json:"transSerialNo"` // 请求流水号
+		State         int    `json:"state"`         // 修改状态 1成功 2失败 3处理中
+		Remark        string `json:"remark"`        // 失败原因
+	} `json:"body"`
+	Header AccountRespHeader `json:"header"`
+}
+
+// AccountModifyQueryRequest 修改账户查询请求参数
+type AccountModifyQueryRequest struct {
+	ContractNo    string `json:"contractNo"`    // 客户账户号
+	TransSerialNo string `json:"transSerialNo"` // 修改请求流水号
+}
+
+// SettleCard 结算银行卡信息
+type SettleCard struct {
+	CardNo              string `json:"cardNo"`              // 银行卡号
+	CardUserName        string `json:"cardUserName"`        // 持卡人姓名（对公卡为企业名称）
+	CardType            string `json:"cardType"`            // 卡类型 1对私 2对公
+	BankName            string `json:"bankName"`            // 银行名称
+	DepositBankProvince string `json:"depositBankProvince"` // 开户行省份
+	DepositBankCity     string `json:"depositBankCity"`     // 开户行城市
+	DepositBankName     string `json:"depositBankName"`     // 开户支行名称
+	Mobile              string `json:"mobile"`              // 银行预留手机号（对私卡验证用）
+}
+
+// BindCardRequest 绑定结算卡请求参数
+type BindCardRequest struct {
+	ContractNo    string     // 客户账户号
+	TransSerialNo string     // 请求流水号，为空时自动生成
+	NoticeUrl     string     // 卡验证结果异步通知地址
+	Card          SettleCard // 结算卡信息
+}
+
+// UnbindCardRequest 解绑结算卡请求参数
+type UnbindCardRequest struct {
+	ContractNo    string // 客户账户号
+	TransSerialNo string // 请求流水号，为空时自动生成
+	CardNo        string // 待解绑银行卡号
+}
+
+// ReplaceCardRequest 更换结算卡请求参数
+type ReplaceCardRequest struct {
+	ContractNo    string     // 客户账户号
+	TransSerialNo string     // 请求流水号，为空时自动生成
+	NoticeUrl     string     // 卡验证结果异步通知地址
+	OldCardNo     string     // 原银行卡号
+	Card          SettleCard // 新结算卡信息
+}
+
+// 结算卡状态
+const (
+	CardStateSuccess   = 1 // 绑定成功
+	CardStateFail      = 2 // 绑定失败
+	CardStateVerifying = 3 // 验证中，结果以异步通知为准
+)
+
+// CardResponse 绑定、解绑、更换结算卡响应
+type CardResponse struct {
+	Body struct {
+		RetCode       int    `json:"retCode"`       // 返回码 1 成功 0 失败
+		ErrorCode     string `json:"errorCode"`     // 错误码
+		ErrorMsg      string `json:"errorMsg"`      // 错误原因
+		ContractNo    string `json:"contractNo"`    // 客户账户号
+		TransSerialNo string `json:"transSerialNo"` // 请求流水号
+		CardNo        string `json:"cardNo"`        // 银行卡号
+		State         int    `json:"state"`         // 状态 1成功 2失败 3验证中
+		Remark        string `json:"remark"`        // 失败原因
+	} `json:"body"`
+	Header AccountRespHeader `json:"header"`
+}
+
+// BoundCard 已绑定的结算卡
+type BoundCard struct {
+	CardNo          string `json:"cardNo"`          // 银行卡号（脱敏）
+	CardUserName    string `json:"cardUserName"`    // 持卡人姓名
+	CardType        string `json:"cardType"`        // 卡类型 1对私 2对公
+	BankName        string `json:"bankName"`        // 银行名称
+	DepositBankName string `json:"depositBankName"` // 开户支行名称
+	IsDefault       bool   `json:"isDefault"`       // 是否默认结算卡
+	State           int    `json:"state"`           // 状态 1成功 2失败 3验证中
+	BindTime        string `json:"bindTime"`        // 绑定时间
+}
+
+// CardQueryResponse 结算卡查询响应
+type CardQueryResponse struct {
+	Body struct {
+		RetCode    int         `json:"retCode"`    // 返回码 1 成功 0 失败
+		ErrorCode  string      `json:"errorCode"`  // 错误码
+		ErrorMsg   string      `json:"errorMsg"`   // 错误原因
+		ContractNo string      `json:"contractNo"` // 客户账户号
+		Cards      []BoundCard `json:"cards"`      // 已绑定结算卡列表
+	} `json:"body"`
+	Header AccountRespHeader `json:"header"`
+}
+
+// CardNotify 结算卡验证结果异步通知
+type CardNotify struct {
+	ContractNo    string `json:"contractNo"`    // 客户账户号
+	TransSerialNo string `json:"transSerialNo"` // 请求流水号
+	CardNo        string `json:"cardNo"`        // 银行卡号
+	State         int    `json:"state"`         // 状态 1成功 2失败
+	Remark        string `json:"remark"`        // 失败原因
+}
+
 // BalanceQueryRequest 余额查询请求参数
 type BalanceQueryRequest struct {
 	AcctType   string `json:"acctType"`   // 账户类型：1个人，2商户
@@ -134,6 +388,204 @@ type TransferResponse struct {
 	} `json:"header"`
 }
 
+// TransferQueryRequest 转账查询请求参数
+type TransferQueryRequest struct {
+	TransSerialNo string `json:"transSerialNo"` // 原转账请求流水号
+	TradeTime     string `json:"tradeTime"`     // 原转账交易日期 yyyyMMdd，选传
+}
+
+// TransferQueryResponse 转账查询响应
+type TransferQueryResponse struct {
+	Body struct {
+		RetCode       int     `json:"retCode"`       // 返回码 1 成功 0 失败
+		ErrorCode     string  `json:"errorCode"`     // 错误码
+		ErrorMsg      string  `json:"errorMsg"`      // 错误原因
+		TransSerialNo string  `json:"transSerialNo"` // 请求流水号
+		BusinessNo    string  `json:"businessNo"`    // 业务流水号
+		PayerNo       string  `json:"payerNo"`       // 付款方(二级子商户号)
+		PayeeNo       string  `json:"payeeNo"`       // 收款方(二级子商户号)
+		DealAmount    float64 `json:"dealAmount"`    // 转账金额,单位：元
+		FeeAmount     float64 `json:"feeAmount"`     // 手续费金额,单位：元
+		State         int     `json:"state"`         // 订单状态 1成功 2失败 3处理中
+		TransRemark   string  `json:"transRemark"`   // 失败原因
+		SuccessTime   string  `json:"successTime"`   // 转账成功时间
+	} `json:"body"`
+	Header AccountRespHeader `json:"header"`
+}
+
+// FreezeRequest 资金冻结请求参数
+type FreezeRequest struct {
+	ContractNo    string  `json:"contractNo"`    // 客户账户号
+	TransSerialNo string  `json:"transSerialNo"` // 冻结请求流水号，商户自定义且唯一
+	DealAmount    float64 `json:"dealAmount"`    // 冻结金额,单位：元，不得大于可用余额
+	Remark        string  `json:"remark"`        // 冻结原因
+}
+
+// UnfreezeRequest 资金解冻请求参数
+type UnfreezeRequest struct {
+	ContractNo        string  `json:"contractNo"`        // 客户账户号
+	TransSerialNo     string  `json:"transSerialNo"`     // 解冻请求流水号，商户自定义且唯一
+	OrigTransSerialNo string  `json:"origTransSerialNo"` // 原冻结请求流水号
+	DealAmount        float64 `json:"dealAmount"`        // 解冻金额,单位：元，不得大于原冻结剩余金额
+	Remark            string  `json:"remark"`            // 解冻原因
+}
+
+// FreezeResponse 资金冻结、解冻及查询响应
+type FreezeResponse struct {
+	Body struct {
+		RetCode           int     `json:"retCode"`           // 返回码 1 成功 0 失败
+		ErrorCode         string  `json:"errorCode"`         // 错误码
+		ErrorMsg          string  `json:"errorMsg"`          // 错误原因
+		ContractNo        string  `json:"contractNo"`        // 客户账户号
+		TransSerialNo     string  `json:"transSerialNo"`     // 请求流水号
+		OrigTransSerialNo string  `json:"origTransSerialNo"` // 原冻结请求流水号（解冻时返回）
+		BusinessNo        string  `json:"businessNo"`        // 业务流水号
+		DealAmount        float64 `json:"dealAmount"`        // 冻结/解冻金额,单位：元
+		FrozenBal         float64 `json:"frozenBal"`         // 原冻结剩余未解冻金额,单位：元
+		State             int     `json:"state"`             // 订单状态 1成功 2失败 3处理中
+		TransRemark       string  `json:"transRemark"`       // 失败原因
+	} `json:"body"`
+	Header AccountRespHeader `json:"header"`
+}
+
+// FreezeQueryRequest 冻结/解冻查询请求参数
+type FreezeQueryRequest struct {
+	TransSerialNo string `json:"transSerialNo"` // 冻结或解冻请求流水号
+	TradeTime     string `json:"tradeTime"`     // 交易日期 yyyyMMdd，选传
+}
+
+// AccountDetailQueryRequest 账户明细查询请求参数
+type AccountDetailQueryRequest struct {
+	ContractNo string `json:"contractNo"` // 客户账户号
+	StartDate  string `json:"startDate"`  // 开始日期 yyyyMMdd
+	EndDate    string `json:"endDate"`    // 结束日期 yyyyMMdd，与开始日期间隔不超过31天
+	PageNo     int    `json:"pageNo"`     // 页码，从1开始
+	PageSize   int    `json:"pageSize"`   // 每页条数，默认20，最大100
+}
+
+// AccountDetail 账户明细
+type AccountDetail struct {
+	BusinessNo     string  `json:"businessNo"`     // 业务流水号
+	TransSerialNo  string  `json:"transSerialNo"`  // 商户请求流水号
+	TradeType      string  `json:"tradeType"`      // 交易类型，如入账、提现、转账、冻结、解冻
+	Direction      string  `json:"direction"`      // 资金方向 IN收入 OUT支出
+	DealAmount     float64 `json:"dealAmount"`     // 发生金额,单位：元
+	Balance        float64 `json:"balance"`        // 变动后账簿余额,单位：元
+	CounterpartyNo string  `json:"counterpartyNo"` // 对方账户号
+	CounterpartyNm string  `json:"counterpartyNm"` // 对方账户名称
+	TradeTime      string  `json:"tradeTime"`      // 交易时间
+	Remark         string  `json:"remark"`         // 备注
+}
+
+// AccountDetailQueryResponse 账户明细查询响应
+type AccountDetailQueryResponse struct {
+	Body struct {
+		RetCode    int             `json:"retCode"`    // 返回码 1 成功 0 失败
+		ErrorCode  string          `json:"errorCode"`  // 错误码
+		ErrorMsg   string          `json:"errorMsg"`   // 错误原因
+		ContractNo string          `json:"contractNo"` // 客户账户号
+		PageNo     int             `json:"pageNo"`     // 当前页码
+		PageSize   int             `json:"pageSize"`   // 每页条数
+		TotalCount int             `json:"totalCount"` // 总条数
+		Details    []AccountDetail `json:"details"`    // 明细列表
+	} `json:"body"`
+	Header AccountRespHeader `json:"header"`
+}
+
+// CloseAccountRequest 销户请求参数
+type CloseAccountRequest struct {
+	ContractNo    string `json:"contractNo"`    // 客户账户号
+	AccType       string `json:"accType"`       // 账户类型 1个人 2商户
+	TransSerialNo string `json:"transSerialNo"` // 请求流水号，为空时自动生成
+	NoticeUrl     string `json:"noticeUrl"`     // 销户结果异步通知地址
+	Reason        string `json:"reason"`        // 销户原因
+}
+
+// 销户状态
+const (
+	CloseAccountStateSuccess    = 1 // 销户成功
+	CloseAccountStateFail       = 2 // 销户失败
+	CloseAccountStateProcessing = 3 // 销户处理中
+)
+
+// CloseAccountResponse 销户及销户查询响应
+type CloseAccountResponse struct {
+	Body struct {
+		RetCode       int    `json:"retCode"`       // 返回码 1 成功 0 失败
+		ErrorCode     string `json:"errorCode"`     // 错误码
+		ErrorMsg      string `json:"errorMsg"`      // 错误原因
+		ContractNo    string `json:"contractNo"`    // 客户账户号
+		TransSerialNo string `json:"transSerialNo"` // 请求流水号
+		State         int    `json:"state"`         // 销户状态 1成功 2失败 3处理中
+		Remark        string `json:"remark"`        // 失败原因
+		FinishTime    string `json:"finishTime"`    // 销户完成时间
+	} `json:"body"`
+	Header AccountRespHeader `json:"header"`
+}
+
+// CloseAccountQueryRequest 销户查询请求参数
+type CloseAccountQueryRequest struct {
+	ContractNo    string `json:"contractNo"`    // 客户账户号
+	TransSerialNo string `json:"transSerialNo"` // 销户请求流水号
+}
+
+// 充值方式
+const (
+	RechargeTypeCard = "CARD" // 银行卡充值（代扣账户绑定的结算卡）
+	RechargeTypeJuhe = "JUHE" // 聚合支付充值（聚合支付订单资金入账）
+)
+
+// RechargeRequest 账户充值请求参数
+type RechargeRequest struct {
+	ContractNo    string  `json:"contractNo"`    // 充值入账的客户账户号
+	TransSerialNo string  `json:"transSerialNo"` // 充值请求流水号，商户自定义且唯一
+	RechargeType  string  `json:"rechargeType"`  // 充值方式 CARD银行卡 JUHE聚合支付
+	DealAmount    float64 `json:"dealAmount"`    // 充值金额,单位：元
+	CardNo        string  `json:"cardNo"`        // 扣款银行卡号，银行卡充值必传
+	OrigTradeNo   string  `json:"origTradeNo"`   // 聚合支付宝付交易号，聚合支付充值必传
+	NoticeUrl     string  `json:"noticeUrl"`     // 充值结果异步通知地址
+	ReqReserved   string  `json:"reqReserved"`   // 原样返回保留字段
+}
+
+// RechargeResponse 账户充值及充值查询响应
+type RechargeResponse struct {
+	Body struct {
+		RetCode       int     `json:"retCode"`       // 返回码 1 成功 0 失败
+		ErrorCode     string  `json:"errorCode"`     // 错误码
+		ErrorMsg      string  `json:"errorMsg"`      // 错误原因
+		ContractNo    string  `json:"contractNo"`    // 客户账户号
+		TransSerialNo string  `json:"transSerialNo"` // 请求流水号
+		BusinessNo    string  `json:"businessNo"`    // 业务流水号
+		RechargeType  string  `json:"rechargeType"`  // 充值方式
+		DealAmount    float64 `json:"dealAmount"`    // 充值金额,单位：元
+		FeeAmount     float64 `json:"feeAmount"`     // 手续费金额,单位：元
+		State         int     `json:"state"`         // 订单状态 1成功 2失败 3处理中
+		TransRemark   string  `json:"transRemark"`   // 失败原因
+		SuccessTime   string  `json:"successTime"`   // 充值成功时间
+		ReqReserved   string  `json:"reqReserved"`   // 原样返回保留字段
+	} `json:"body"`
+	Header AccountRespHeader `json:"header"`
+}
+
+// RechargeQueryRequest 充值查询请求参数
+type RechargeQueryRequest struct {
+	TransSerialNo string `json:"transSerialNo"` // 充值请求流水号
+	TradeTime     string `json:"tradeTime"`     // 交易日期 yyyyMMdd，选传
+}
+
+// RechargeNotify 充值结果异步通知
+type RechargeNotify struct {
+	ContractNo    string  `json:"contractNo"`    // 客户账户号
+	TransSerialNo string  `json:"transSerialNo"` // 请求流水号
+	BusinessNo    string  `json:"businessNo"`    // 业务流水号
+	DealAmount    float64 `json:"dealAmount"`    // 充值金额,单位：元
+	FeeAmount     float64 `json:"feeAmount"`     // 手续费金额,单位：元
+	State         int     `json:"state"`         // 订单状态 1成功 2失败
+	TransRemark   string  `json:"transRemark"`   // 失败原因
+	SuccessTime   string  `json:"successTime"`   // 充值成功时间
+	ReqReserved   string  `json:"reqReserved"`   // 原样返回保留字段
+}
+
 // WithdrawRequest 提现请求参数
 type WithdrawRequest struct {
 	Version          string  `json:"version"`          // 版本号
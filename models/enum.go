@@ -10,3 +10,14 @@ const (
 	REFUND      TxnState = "REFUND"      // 支付订单已退款
 	ABNORMAL    TxnState = "ABNORMAL"    // 支付异常，返回此状态的支付订单，请稍后发起查询。
 )
+
+// 支付方式
+const (
+	PayCodeWechatJSAPI    = "WECHAT_JSAPI"    // 微信公众号支付
+	PayCodeWechatApplet   = "WECHAT_APPLET"   // 微信小程序支付
+	PayCodeWechatNative   = "WECHAT_NATIVE"   // 微信扫码支付（主扫）
+	PayCodeWechatMicroPay = "WECHAT_MICROPAY" // 微信付款码支付（被扫）
+	PayCodeAlipayJSAPI    = "ALIPAY_JSAPI"    // 支付宝服务窗/小程序支付
+	PayCodeAlipayNative   = "ALIPAY_NATIVE"   // 支付宝扫码支付（主扫）
+	PayCodeAlipayMicroPay = "ALIPAY_MICROPAY" // 支付宝付款码支付（被扫）
+)
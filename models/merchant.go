@@ -1,5 +1,10 @@
 package models
 
+import (
+	"fmt"
+	"strings"
+)
+
 // PayResponse 支付响应
 type Response struct {
 	ReturnCode  string `json:"returnCode"`  // 返回码
@@ -60,7 +65,7 @@ type MerchantReportQueryRequest struct {
 	//AgentTerId  string `json:"agentTerId"`  // 代理商终端号
 	MerId      string `json:"merId"`      // 交易商户号
 	TerId      string `json:"terId"`      // 交易终端号
-	ReportType string `json:"reportType"` // 报备类型 WECHAT
+	ReportType string `json:"reportType"` // 报备类型 WECHAT ALIPAY
 	ReportNo   string `json:"reportNo"`   // 报备编号
 }
 
@@ -75,3 +80,197 @@ type MerchantBindSubConfigRequest struct {
 	AuthContent string `json:"authContent"` // 授权内容
 	Remark      string `json:"remark"`      // 备注
 }
+
+// 报备类型
+const (
+	ReportTypeWechat = "WECHAT" // 微信报备
+	ReportTypeAlipay = "ALIPAY" // 支付宝报备
+)
+
+// MerchantAlipayReportReq 商户报备支付宝请求参数
+type MerchantAlipayReportReq struct {
+	MerId      string           `json:"merId"`      // 交易商户号
+	TerId      string           `json:"terId"`      // 交易终端号
+	ReportType string           `json:"reportType"` // 报备类型 ALIPAY
+	ReportNo   string           `json:"reportNo"`   // 报备编号，为空时自动生成
+	ReportInfo AlipayReportInfo `json:"reportInfo"` // 报备信息
+	BctMerId   string           `json:"bctMerId"`   // 宝财通二级商户号
+}
+
+// AlipayReportInfo 支付宝报备信息
+type AlipayReportInfo struct {
+	Name                string              `json:"name"`                            // 商户名称
+	AliasName           string              `json:"alias_name"`                      // 商户简称
+	ServicePhone        string              `json:"service_phone"`                   // 客服电话
+	CategoryId          string              `json:"category_id"`                     // 经营类目（MCC）
+	Source              string              `json:"source"`                          // 渠道商商户号
+	ContactInfo         []AlipayContactInfo `json:"contact_info"`                    // 联系人信息
+	AddressInfo         []AlipayAddressInfo `json:"address_info"`                    // 经营地址信息
+	BusinessLicense     string              `json:"business_license,omitempty"`      // 商户证件编号
+	BusinessLicenseType string              `json:"business_license_type,omitempty"` // 商户证件类型
+	BankcardInfo        *BankcardInfo       `json:"bankcard_info,omitempty"`         // 银行结算卡信息
+	Memo                string              `json:"memo,omitempty"`                  // 备注
+}
+
+// AlipayContactInfo 支付宝报备联系人信息
+type AlipayContactInfo struct {
+	Name     string `json:"name"`                 // 联系人姓名
+	Mobile   string `json:"mobile"`               // 手机号
+	Phone    string `json:"phone,omitempty"`      // 座机
+	Email    string `json:"email,omitempty"`      // 邮箱
+	Type     string `json:"type,omitempty"`       // 联系人类型 LEGAL_PERSON法人 CONTROLLER实际控制人 AGENT代理人 OTHER其他
+	IdCardNo string `json:"id_card_no,omitempty"` // 身份证号
+}
+
+// AlipayAddressInfo 支付宝报备地址信息
+type AlipayAddressInfo struct {
+	ProvinceCode string `json:"province_code"`       // 省份编码
+	CityCode     string `json:"city_code"`           // 城市编码
+	DistrictCode string `json:"district_code"`       // 区县编码
+	Address      string `json:"address"`             // 详细地址
+	Longitude    string `json:"longitude,omitempty"` // 经度
+	Latitude     string `json:"latitude,omitempty"`  // 纬度
+	Type         string `json:"type,omitempty"`      // 地址类型 BUSINESS_ADDRESS经营地址 REGISTERED_ADDRESS注册地址
+}
+
+// Validate 校验支付宝报备必填信息
+func (r *MerchantAlipayReportReq) Validate() error {
+	info := r.ReportInfo
+	switch {
+	case info.Name == "":
+		return fmt.Errorf("商户名称不能为空")
+	case info.AliasName == "":
+		return fmt.Errorf("商户简称不能为空")
+	case info.ServicePhone == "":
+		return fmt.Errorf("客服电话不能为空")
+	case info.CategoryId == "":
+		return fmt.Errorf("经营类目不能为空")
+	case len(info.ContactInfo) == 0:
+		return fmt.Errorf("联系人信息不能为空")
+	case len(info.AddressInfo) == 0:
+		return fmt.Errorf("地址信息不能为空")
+	}
+	for i, c := range info.ContactInfo {
+		if c.Name == "" || c.Mobile == "" {
+			return fmt.Errorf("第 %d 个联系人姓名和手机号不能为空", i+1)
+		}
+	}
+	for i, a := range info.AddressInfo {
+		if a.ProvinceCode == "" || a.CityCode == "" || a.DistrictCode == "" || a.Address == "" {
+			return fmt.Errorf("第 %d 个地址的省市区编码和详细地址不能为空", i+1)
+		}
+	}
+	return nil
+}
+
+// ReportStatus 报备状态
+type ReportStatus string
+
+const (
+	ReportStatusProcessing ReportStatus = "PROCESSING" // 审核中
+	ReportStatusSuccess    ReportStatus = "SUCCESS"    // 报备成功
+	ReportStatusFail       ReportStatus = "FAIL"       // 报备失败（驳回）
+)
+
+// MerchantReportResult 商户报备及报备查询结果
+type MerchantReportResult struct {
+	MerId        string       `json:"merId"`        // 交易商户号
+	TerId        string       `json:"terId"`        // 交易终端号
+	ReportType   string       `json:"reportType"`   // 报备类型 WECHAT ALIPAY
+	ReportNo     string       `json:"reportNo"`     // 报备编号
+	ReportStatus ReportStatus `json:"reportStatus"` // 报备状态
+	SubMchId     string       `json:"subMchId"`     // 微信子商户号 sub_mch_id / 支付宝二级商户识别码
+	RejectReason string       `json:"rejectReason"` // 驳回原因
+	ResultCode   string       `json:"resultCode"`   // 业务结果 SUCCESS FAIL
+	ErrCode      string       `json:"errCode"`      // 错误码
+	ErrMsg       string       `json:"errMsg"`       // 错误信息
+	Raw          string       `json:"-"`            // 宝付返回的原始业务数据
+}
+
+// Finished 报备是否已审核完成（成功或驳回）
+func (r *MerchantReportResult) Finished() bool {
+	return r.ReportStatus == ReportStatusSuccess || r.ReportStatus == ReportStatusFail
+}
+
+// MerchantBindSubConfigResult 绑定授权目录结果
+type MerchantBindSubConfigResult struct {
+	MerId       string `json:"merId"`       // 交易商户号
+	TerId       string `json:"terId"`       // 交易终端号
+	SubMchId    string `json:"subMchId"`    // 商户识别码
+	AuthType    string `json:"authType"`    // 授权类型
+	AuthContent string `json:"authContent"` // 授权内容
+	ResultCode  string `json:"resultCode"`  // 业务结果 SUCCESS FAIL
+	ErrCode     string `json:"errCode"`     // 错误码
+	ErrMsg      string `json:"errMsg"`      // 错误信息
+	Raw         string `json:"-"`           // 宝付返回的原始业务数据
+}
+
+// 授权类型
+const (
+	AuthTypeJSAPI  = "JSAPI"  // 公众号支付授权目录
+	AuthTypeApplet = "APPLET" // 小程序/公众号 appid
+)
+
+// MerchantQuerySubConfigRequest 查询授权目录请求参数
+type MerchantQuerySubConfigRequest struct {
+	MerId    string `json:"merId"`    // 交易商户号
+	TerId    string `json:"terId"`    // 交易终端号
+	SubMchId string `json:"subMchId"` // 商户识别码
+}
+
+// MerchantSubConfig 子商户当前授权配置
+type MerchantSubConfig struct {
+	SubMchId        string        `json:"subMchId"`        // 商户识别码
+	JsapiPathList   []string      `json:"jsapiPathList"`   // 已绑定的支付授权目录
+	AppidConfigList []AppidConfig `json:"appidConfigList"` // 已绑定的appid
+	Raw             string        `json:"-"`               // 宝付返回的原始业务数据
+}
+
+// AppidConfig 已绑定的appid配置
+type AppidConfig struct {
+	SubAppid       string `json:"subAppid"`       // 绑定的appid
+	SubscribeAppid string `json:"subscribeAppid"` // 推荐关注的appid
+}
+
+// Bound 授权内容是否已绑定，不支持查询的授权类型返回false
+func (c *MerchantSubConfig) Bound(authType, authContent string) bool {
+	authContent = strings.TrimSpace(authContent)
+	switch authType {
+	case AuthTypeJSAPI:
+		for _, path := range c.JsapiPathList {
+			if strings.TrimSpace(path) == authContent {
+				return true
+			}
+		}
+	case AuthTypeApplet:
+		for _, appid := range c.AppidConfigList {
+			if strings.TrimSpace(appid.SubAppid) == authContent {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// SubConfigEntry 待绑定的授权配置
+type SubConfigEntry struct {
+	AuthType    string `json:"authType"`    // 授权类型 JSAPI APPLET
+	AuthContent string `json:"authContent"` // 授权内容 支付授权目录或appid
+	Remark      string `json:"remark"`      // 备注
+}
+
+// SubConfigBindStatus 授权配置绑定结果状态
+type SubConfigBindStatus string
+
+const (
+	SubConfigBindSuccess SubConfigBindStatus = "SUCCESS" // 绑定成功并已查询确认
+	SubConfigBindSkipped SubConfigBindStatus = "SKIPPED" // 已绑定，跳过
+	SubConfigBindFail    SubConfigBindStatus = "FAIL"    // 绑定失败或绑定后查询未生效
+)
+
+// SubConfigBindResult 单条授权配置的绑定结果
+type SubConfigBindResult struct {
+	SubConfigEntry
+	Status SubConfigBindStatus `json:"status"` // 绑定结果状态
+	ErrMsg string              `json:"errMsg"` // 失败原因
+}
@@ -1,5 +1,10 @@
 package models
 
+import (
+	"encoding/json"
+	"fmt"
+)
+
 type UnifiedOrderRequest struct {
 	OutTradeNo   string // 商户订单号
 	Amount       int    // 交易金额（分）
@@ -11,6 +16,13 @@ type UnifiedOrderRequest struct {
 	Attach       string // 附加数据
 	ForbidCredit string // 是否禁止信用卡支付 1禁止 0或者""不禁用
 	NotifyURL    string // 异步通知地址
+	AuthCode     string // 付款码 条码支付(MICROPAY)必传，扫描用户付款码获得
+	DeviceID     string // 终端设备号 条码支付(MICROPAY)必传，门店收银设备ID
+	DeviceIP     string // 终端设备IP 条码支付(MICROPAY)选传
+
+	MktInfo []MktInfo // 营销信息 订单总金额=交易金额+营销总金额
+
+	SharingDetails []SharingDetails // 实时分账信息 支付成功后按明细实时结算给分账商户，为空则需支付后调用分账接口
 }
 
 // BizContent 业务参数
@@ -33,6 +45,45 @@ type BizContent struct {
 	ForbidCredit string    `json:"forbidCredit"`         // 是否禁止信用卡支付
 	Attach       string    `json:"attach,omitempty"`     // 附加数据
 	RiskInfo     RiskInfo  `json:"riskInfo,omitempty"`   // 风控信息
+	MktInfo      []MktInfo `json:"mktInfo,omitempty"`    // 营销信息
+
+	SharingDetails []SharingDetails `json:"sharingDetails,omitempty"` // 实时分账信息
+}
+
+// MktInfo 营销信息
+type MktInfo struct {
+	MktMerId string `json:"mktMerId"` // 营销出资方商户号
+	MktAmt   int    `json:"mktAmt"`   // 营销金额，单位：分
+}
+
+// MktTotalAmt 营销总金额
+func (r *UnifiedOrderRequest) MktTotalAmt() int {
+	total := 0
+	for _, m := range r.MktInfo {
+		total += m.MktAmt
+	}
+	return total
+}
+
+// Validate 校验交易金额、实时分账明细及营销信息
+func (r *UnifiedOrderRequest) Validate() error {
+	if r.Amount <= 0 {
+		return fmt.Errorf("交易金额必须大于0")
+	}
+	if len(r.SharingDetails) > 0 {
+		if err := ValidateSharingDetails(r.SharingDetails, r.Amount); err != nil {
+			return err
+		}
+	}
+	for _, m := range r.MktInfo {
+		if m.MktMerId == "" {
+			return fmt.Errorf("营销出资方商户号不能为空")
+		}
+		if m.MktAmt <= 0 {
+			return fmt.Errorf("营销金额必须大于0: %s", m.MktMerId)
+		}
+	}
+	return nil
 }
 
 // PayExtend 支付扩展信息
@@ -42,6 +93,7 @@ type PayExtend struct {
 	SubAppID     string        `json:"sub_appid,omitempty"`     // 子应用ID
 	SubOpenID    string        `json:"sub_openid,omitempty"`    // 子用户OpenID
 	TerminalInfo *TerminalInfo `json:"terminal_info,omitempty"` // 终端信息
+	AuthCode     string        `json:"auth_code,omitempty"`     // 付款码 条码支付必传
 
 	// 支付宝参数
 	Subject  string `json:"subject,omitempty"`   // 商品名称
@@ -51,8 +103,9 @@ type PayExtend struct {
 
 // TerminalInfo 终端信息
 type TerminalInfo struct {
-	PayCode  string `json:"pay_code"`  // 支付方式
-	DeviceID string `json:"device_id"` // 设备ID
+	PayCode  string `json:"pay_code"`            // 支付方式
+	DeviceID string `json:"device_id"`           // 设备ID
+	DeviceIP string `json:"device_ip,omitempty"` // 设备IP
 }
 
 // RiskInfo 风控信息
@@ -89,6 +142,61 @@ type ChlRetParam struct {
 	WcPayData string `json:"wc_pay_data"` // 微信支付参数
 	OrderID   int    `json:"order_id"`    // 订单ID
 	PrepayID  string `json:"prepay_id"`   // 预支付ID
+	TradeNO   string `json:"trade_no"`    // 支付宝交易号 ALIPAY_JSAPI 返回，用于 my.tradePay
+	CodeURL   string `json:"code_url"`    // 微信二维码链接 WECHAT_NATIVE 返回
+	QrCode    string `json:"qr_code"`     // 支付宝二维码链接 ALIPAY_NATIVE 返回
+}
+
+// WcPayData 微信前端调起支付参数，对应 WeixinJSBridge getBrandWCPayRequest / wx.requestPayment
+type WcPayData struct {
+	AppID     string `json:"appId"`     // 公众号/小程序AppID
+	TimeStamp string `json:"timeStamp"` // 时间戳
+	NonceStr  string `json:"nonceStr"`  // 随机字符串
+	Package   string `json:"package"`   // 订单详情扩展字符串 prepay_id=***
+	SignType  string `json:"signType"`  // 签名方式
+	PaySign   string `json:"paySign"`   // 签名
+}
+
+// PayParams 前端支付参数，按订单支付方式只填充其中一项
+type PayParams struct {
+	PayCode   string     // 支付方式
+	Wechat    *WcPayData // 微信公众号/小程序支付参数 WECHAT_JSAPI WECHAT_APPLET
+	TradeNO   string     // 支付宝交易号 ALIPAY_JSAPI
+	QrCodeURL string     // 二维码链接 WECHAT_NATIVE ALIPAY_NATIVE
+}
+
+// PayParams 根据订单支付方式解析渠道返回参数，得到前端可直接使用的支付参数
+func (d *UnifiedOrderDataContent) PayParams() (*PayParams, error) {
+	params := &PayParams{PayCode: d.PayCode}
+
+	switch d.PayCode {
+	case PayCodeWechatJSAPI, PayCodeWechatApplet:
+		if d.ChlRetParam.WcPayData == "" {
+			return nil, fmt.Errorf("渠道未返回微信支付参数")
+		}
+		var data WcPayData
+		if err := json.Unmarshal([]byte(d.ChlRetParam.WcPayData), &data); err != nil {
+			return nil, fmt.Errorf("解析微信支付参数失败: %v", err)
+		}
+		params.Wechat = &data
+	case PayCodeAlipayJSAPI:
+		if d.ChlRetParam.TradeNO == "" {
+			return nil, fmt.Errorf("渠道未返回支付宝交易号")
+		}
+		params.TradeNO = d.ChlRetParam.TradeNO
+	case PayCodeWechatNative, PayCodeAlipayNative:
+		params.QrCodeURL = d.ChlRetParam.CodeURL
+		if params.QrCodeURL == "" {
+			params.QrCodeURL = d.ChlRetParam.QrCode
+		}
+		if params.QrCodeURL == "" {
+			return nil, fmt.Errorf("渠道未返回二维码链接")
+		}
+	default:
+		return nil, fmt.Errorf("支付方式 %s 无前端支付参数", d.PayCode)
+	}
+
+	return params, nil
 }
 
 // QueryOrderRequest 订单查询请求
@@ -127,7 +235,35 @@ type QueryOrderData struct {
 		OpenID    string `json:"openId"`    // 用户在服务商公众号appid下的唯一标识
 		SubOpenID string `json:"subOpenid"` // 微信平台的sub_openid
 	} `json:"chlRetParam"` // 渠道返回参数
-	ClearingDate string `json:"clearingDate"` // 清算日期
+	ClearingDate   string          `json:"clearingDate"`             // 清算日期
+	SharingDetails []SharingResult `json:"sharingDetails,omitempty"` // 实时分账结果
+}
+
+// SharingResult 实时分账结果
+type SharingResult struct {
+	SharingMerId string `json:"sharingMerId"` // 分账商户号
+	SharingAmt   int    `json:"sharingAmt"`   // 分账金额，单位：分
+	SharingState string `json:"sharingState"` // 分账状态 SUCCESS：成功
+	ErrMsg       string `json:"errMsg"`       // 失败原因
+}
+
+// PayNotifyData 支付结果异步通知数据
+type PayNotifyData struct {
+	MerID          string          `json:"merId"`                    // 商户号
+	TerID          string          `json:"terId"`                    // 终端号
+	TradeNo        string          `json:"tradeNo"`                  // 宝付交易号
+	OutTradeNo     string          `json:"outTradeNo"`               // 商户订单号
+	TxnState       TxnState        `json:"txnState"`                 // 订单状态
+	FinishTime     string          `json:"finishTime"`               // 完成时间
+	SuccAmt        int             `json:"succAmt"`                  // 成功金额
+	FeeAmt         int             `json:"feeAmt"`                   // 支付手续费
+	PayCode        string          `json:"payCode"`                  // 支付方式
+	Attach         string          `json:"attach"`                   // 附加数据，下单时原样返回
+	ResultCode     string          `json:"resultCode"`               // 业务结果
+	ErrCode        string          `json:"errCode"`                  // 错误代码
+	ErrMsg         string          `json:"errMsg"`                   // 错误描述
+	ClearingDate   string          `json:"clearingDate"`             // 清算日期
+	SharingDetails []SharingResult `json:"sharingDetails,omitempty"` // 实时分账结果
 }
 
 type ShareOrderRequest struct {
@@ -206,9 +342,41 @@ type RefundRequest struct {
 	TxnTime          string `json:"txnTime"`                    // 交易时间 订单交易时间
 
 	SharingRefundInfo []SharingRefundInfo `json:"sharingRefundInfo,omitempty"` // 分账退款信息
-	// MktRefundInfo     []MktRefundInfo     `json:"mktRefundInfo,omitempty"`     // 营销退款信息
-	//AdvanceAmt        int                 `json:"advanceAmt,omitempty"`        // 垫资金额
-	RefundReason string `json:"refundReason"` // 退款原因
+	MktRefundInfo     []MktRefundInfo     `json:"mktRefundInfo,omitempty"`     // 营销退款信息
+	AdvanceAmt        int                 `json:"advanceAmt,omitempty"`        // 垫资金额 单位：分，不得大于退款金额
+	RefundReason      string              `json:"refundReason"`                // 退款原因
+}
+
+// MktRefundTotalAmt 营销退款总金额
+func (r *RefundRequest) MktRefundTotalAmt() int {
+	total := 0
+	for _, m := range r.MktRefundInfo {
+		total += m.MktAmt
+	}
+	return total
+}
+
+// Validate 校验退款金额
+// 退款总金额=退款金额+营销退款总金额，垫资金额在0到退款金额之间
+func (r *RefundRequest) Validate() error {
+	if r.RefundAmt <= 0 {
+		return fmt.Errorf("退款金额必须大于0")
+	}
+	for _, m := range r.MktRefundInfo {
+		if m.MktMerId == "" {
+			return fmt.Errorf("营销出资方商户号不能为空")
+		}
+		if m.MktAmt <= 0 {
+			return fmt.Errorf("营销退款金额必须大于0: %s", m.MktMerId)
+		}
+	}
+	if r.TotalAmt != r.RefundAmt+r.MktRefundTotalAmt() {
+		return fmt.Errorf("退款总金额 %d 不等于退款金额 %d 加营销退款总金额 %d", r.TotalAmt, r.RefundAmt, r.MktRefundTotalAmt())
+	}
+	if r.AdvanceAmt < 0 || r.AdvanceAmt > r.RefundAmt {
+		return fmt.Errorf("垫资金额 %d 超出范围 [0, %d]", r.AdvanceAmt, r.RefundAmt)
+	}
+	return nil
 }
 
 type SharingRefundInfo struct {
@@ -218,7 +386,7 @@ type SharingRefundInfo struct {
 
 type MktRefundInfo struct {
 	MktMerId string `json:"mktMerId"` // 宝付支付分配的商户号
-	MktAmt   int    `json:"mktAmt"`   // 分账金额，单位：分，如1元则传入100
+	MktAmt   int    `json:"mktAmt"`   // 营销退款金额，单位：分，如1元则传入100
 }
 
 type RefundResponse struct {
@@ -254,3 +422,20 @@ type RefundQueryData struct {
 	ErrCode     string      `json:"errCode"`     // 错误代码 当业务结果FAIL时，返回错误代码
 	ErrMsg      string      `json:"errMsg"`      // 错误描述 当业务结果为FAIL时，返回错误描述
 }
+
+// RefundRecord 退款记录，用于跟踪原支付订单的可退余额
+type RefundRecord struct {
+	OriginTradeNo    string      `json:"originTradeNo"`    // 原支付订单宝付交易号
+	OriginOutTradeNo string      `json:"originOutTradeNo"` // 原支付订单商户订单号
+	OutTradeNo       string      `json:"outTradeNo"`       // 商户退款订单号
+	TradeNo          string      `json:"tradeNo"`          // 宝付退款交易号
+	RefundAmt        int         `json:"refundAmt"`        // 退款金额 单位：分
+	TotalAmt         int         `json:"totalAmt"`         // 退款总金额 单位：分
+	RefundState      RefundState `json:"refundState"`      // 退款状态
+	TxnTime          string      `json:"txnTime"`          // 退款交易时间
+}
+
+// Occupied 退款记录是否占用可退金额，仅明确失败的退款不占用
+func (r *RefundRecord) Occupied() bool {
+	return r.RefundState != RefundStateRefundError
+}
@@ -0,0 +1,119 @@
+package models
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strconv"
+)
+
+// JuheReconRecord 聚合支付对账文件记录
+type JuheReconRecord struct {
+	MerID        string // 商户号
+	TerID        string // 终端号
+	TradeNo      string // 宝付交易号
+	OutTradeNo   string // 商户订单号（支付、退款或分账订单号）
+	OriginNo     string // 原支付订单宝付交易号（退款、分账时有值）
+	TxnType      string // 交易类型 PAY支付 REFUND退款 SHARE分账
+	PayCode      string // 支付方式
+	TxnAmt       int64  // 交易金额 单位：分
+	FeeAmt       int64  // 手续费 单位：分
+	SettleAmt    int64  // 清算金额 单位：分
+	TxnState     string // 交易状态
+	TxnTime      string // 交易时间
+	FinishTime   string // 完成时间
+	ClearingDate string // 清算日期
+	Line         int    // 在对账文件中的行号
+}
+
+// AccountReconRecord 账簿对账文件记录
+type AccountReconRecord struct {
+	ContractNo    string // 客户账户号
+	BusinessNo    string // 业务流水号
+	TransSerialNo string // 商户请求流水号
+	TradeType     string // 交易类型 如提现、转账、充值
+	PayerNo       string // 付款方账户号
+	PayeeNo       string // 收款方账户号
+	DealAmount    int64  // 交易金额 单位：分
+	FeeAmount     int64  // 手续费 单位：分
+	State         string // 交易状态
+	TradeTime     string // 交易时间
+	ClearingDate  string // 清算日期
+	Line          int    // 在对账文件中的行号
+}
+
+// LocalReconRecord 商户本地交易记录，由 LocalReconSource 提供给对账引擎
+type LocalReconRecord struct {
+	TxnType    string // 交易类型 PAY支付 REFUND退款 SHARE分账
+	OutTradeNo string // 商户订单号
+	TradeNo    string // 宝付交易号，商户订单号匹配不到时使用
+	Amount     int64  // 交易金额 单位：分，退款可登记为正数或负数，比对时按交易类型统一为负数
+	FeeAmt     *int64 // 手续费 单位：分，为nil时不比对手续费，符号规则同交易金额
+	State      string // 本地订单状态
+}
+
+// ReconDiffType 对账差异类型
+type ReconDiffType string
+
+const (
+	ReconDiffMissingLocal    ReconDiffType = "MISSING_LOCAL"    // 宝付有记录，本地无记录
+	ReconDiffMissingRemote   ReconDiffType = "MISSING_REMOTE"   // 本地成功，宝付无记录
+	ReconDiffAmountMismatch  ReconDiffType = "AMOUNT_MISMATCH"  // 交易金额不一致
+	ReconDiffFeeMismatch     ReconDiffType = "FEE_MISMATCH"     // 手续费不一致
+	ReconDiffStateMismatch   ReconDiffType = "STATE_MISMATCH"   // 交易状态不一致
+	ReconDiffDuplicateRemote ReconDiffType = "DUPLICATE_REMOTE" // 宝付对账文件中重复出现
+)
+
+// ReconDiff 对账差异
+type ReconDiff struct {
+	Type        ReconDiffType `json:"type"`
+	TxnType     string        `json:"txnType,omitempty"`
+	OutTradeNo  string        `json:"outTradeNo,omitempty"`
+	TradeNo     string        `json:"tradeNo,omitempty"`
+	LocalAmt    int64         `json:"localAmt"`
+	RemoteAmt   int64         `json:"remoteAmt"`
+	LocalFee    int64         `json:"localFee"`
+	RemoteFee   int64         `json:"remoteFee"`
+	LocalState  string        `json:"localState,omitempty"`
+	RemoteState string        `json:"remoteState,omitempty"`
+	Line        int           `json:"line,omitempty"` // 在对账文件中的行号
+}
+
+// ReconReport 对账报告
+type ReconReport struct {
+	SettleDate   string      `json:"settleDate"`   // 清算日期
+	RemoteCount  int         `json:"remoteCount"`  // 宝付记录笔数
+	RemoteAmt    int64       `json:"remoteAmt"`    // 宝付记录金额合计，退款按负数计
+	LocalCount   int         `json:"localCount"`   // 本地记录笔数
+	LocalAmt     int64       `json:"localAmt"`     // 本地记录金额合计，退款按负数计
+	MatchedCount int         `json:"matchedCount"` // 无差异笔数
+	Diffs        []ReconDiff `json:"diffs"`        // 差异明细
+}
+
+// Balanced 是否对平
+func (r *ReconReport) Balanced() bool {
+	return len(r.Diffs) == 0
+}
+
+// WriteJSON 以JSON格式输出对账报告
+func (r *ReconReport) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+// WriteCSV 以CSV格式输出差异明细
+func (r *ReconReport) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"type", "txnType", "outTradeNo", "tradeNo", "localAmt", "remoteAmt", "localFee", "remoteFee", "localState", "remoteState", "line"})
+	for _, d := range r.Diffs {
+		cw.Write([]string{
+			string(d.Type), d.TxnType, d.OutTradeNo, d.TradeNo,
+			strconv.FormatInt(d.LocalAmt, 10), strconv.FormatInt(d.RemoteAmt, 10),
+			strconv.FormatInt(d.LocalFee, 10), strconv.FormatInt(d.RemoteFee, 10),
+			d.LocalState, d.RemoteState, strconv.Itoa(d.Line),
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
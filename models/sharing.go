@@ -0,0 +1,470 @@
+package models
+
+import (
+	"fmt"
+	"sort"
+)
+
+// SharingRuleType 分账规则类型
+type SharingRuleType string
+
+const (
+	SharingRuleRatio     SharingRuleType = "RATIO"     // 按比例分账
+	SharingRuleFixed     SharingRuleType = "FIXED"     // 按固定金额分账
+	SharingRuleRemainder SharingRuleType = "REMAINDER" // 剩余金额全部分给该商户
+)
+
+// RoundingMode 按比例分账的取整方式
+type RoundingMode string
+
+const (
+	RoundingFloor    RoundingMode = "FLOOR"     // 向下取整
+	RoundingCeil     RoundingMode = "CEIL"      // 向上取整
+	RoundingHalfUp   RoundingMode = "HALF_UP"   // 四舍五入
+	RoundingHalfEven RoundingMode = "HALF_EVEN" // 银行家舍入
+)
+
+// SharingRule 分账规则
+type SharingRule struct {
+	SharingMerId string          `json:"sharingMerId"`       // 宝付支付分配的商户号
+	Type         SharingRuleType `json:"type"`               // 规则类型
+	RatioBp      int             `json:"ratioBp,omitempty"`  // 分账比例，单位：万分之一，如平台抽佣0.6%则传入60
+	FixedAmt     int             `json:"fixedAmt,omitempty"` // 固定分账金额，单位：分
+}
+
+// SharingPlan 分账方案，可序列化为JSON作为配置保存
+// 计算顺序：先扣除固定金额，再按比例计算（以分账总金额为基数），剩余金额归 REMAINDER 规则的商户
+// 固定金额与比例恰好覆盖分账总金额时不按取整方式计算，取整差额按最大余数法分配给比例规则
+type SharingPlan struct {
+	Rules    []SharingRule `json:"rules"`    // 分账规则
+	Rounding RoundingMode  `json:"rounding"` // 取整方式，默认向下取整
+}
+
+// Validate 校验分账方案
+func (p *SharingPlan) Validate() error {
+	if len(p.Rules) == 0 {
+		return fmt.Errorf("分账规则不能为空")
+	}
+	switch p.Rounding {
+	case "", RoundingFloor, RoundingCeil, RoundingHalfUp, RoundingHalfEven:
+	default:
+		return fmt.Errorf("不支持的取整方式: %s", p.Rounding)
+	}
+
+	remainder, ratio := 0, 0
+	for _, r := range p.Rules {
+		if r.SharingMerId == "" {
+			return fmt.Errorf("分账商户号不能为空")
+		}
+		switch r.Type {
+		case SharingRuleRatio:
+			if r.RatioBp <= 0 || r.RatioBp > 10000 {
+				return fmt.Errorf("分账比例超出范围 (0, 10000]: %s %d", r.SharingMerId, r.RatioBp)
+			}
+			ratio += r.RatioBp
+		case SharingRuleFixed:
+			if r.FixedAmt <= 0 {
+				return fmt.Errorf("固定分账金额必须大于0: %s", r.SharingMerId)
+			}
+		case SharingRuleRemainder:
+			remainder++
+		default:
+			return fmt.Errorf("不支持的分账规则类型: %s", r.Type)
+		}
+	}
+	if remainder > 1 {
+		return fmt.Errorf("剩余金额只能分给一个商户")
+	}
+	if ratio > 10000 {
+		return fmt.Errorf("分账比例合计 %d 超过10000", ratio)
+	}
+	return nil
+}
+
+// Plan 按分账方案将金额拆分为分账明细，明细金额合计恰好等于 amount
+// amount 本次分账总金额，单位：分
+func (p *SharingPlan) Plan(amount int) ([]SharingDetails, error) {
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	if amount <= 0 {
+		return nil, fmt.Errorf("分账金额必须大于0")
+	}
+
+	fixed, ratio := 0, 0
+	for _, r := range p.Rules {
+		switch r.Type {
+		case SharingRuleFixed:
+			fixed += r.FixedAmt
+		case SharingRuleRatio:
+			ratio += r.RatioBp
+		}
+	}
+	// 比例部分的精确金额为 amount*ratio/10000，与固定金额合计恰好等于 amount 时需保证取整后合计不变
+	exact := ratio > 0 && int64(fixed)*10000+int64(amount)*int64(ratio) == int64(amount)*10000
+
+	amounts := make([]int, len(p.Rules))
+	allocated := 0
+	remainderIdx := -1
+	for i, r := range p.Rules {
+		switch r.Type {
+		case SharingRuleFixed:
+			amounts[i] = r.FixedAmt
+		case SharingRuleRatio:
+			if exact {
+				amounts[i] = roundRatio(amount, r.RatioBp, RoundingFloor)
+			} else {
+				amounts[i] = roundRatio(amount, r.RatioBp, p.Rounding)
+			}
+		case SharingRuleRemainder:
+			remainderIdx = i
+			continue
+		}
+		allocated += amounts[i]
+	}
+	if exact {
+		allocated += p.spreadResidue(amounts, amount, amount-allocated)
+	}
+
+	if allocated > amount {
+		return nil, fmt.Errorf("分账金额合计 %d 超过分账总金额 %d", allocated, amount)
+	}
+	if remainderIdx >= 0 {
+		amounts[remainderIdx] = amount - allocated
+	} else if allocated != amount {
+		return nil, fmt.Errorf("分账金额合计 %d 不等于分账总金额 %d，请配置剩余金额规则", allocated, amount)
+	}
+
+	// 合并同一商户的分账金额，并去除0金额明细
+	details := make([]SharingDetails, 0, len(p.Rules))
+	index := make(map[string]int)
+	for i, r := range p.Rules {
+		if amounts[i] == 0 {
+			continue
+		}
+		if j, ok := index[r.SharingMerId]; ok {
+			details[j].SharingAmt += amounts[i]
+			continue
+		}
+		index[r.SharingMerId] = len(details)
+		details = append(details, SharingDetails{
+			SharingMerId: r.SharingMerId,
+			SharingAmt:   amounts[i],
+		})
+	}
+
+	return details, nil
+}
+
+// spreadResidue 按最大余数法将向下取整产生的差额逐分分配给比例规则，余数相同时靠前的规则优先
+// 返回实际分配的金额
+func (p *SharingPlan) spreadResidue(amounts []int, amount, residue int) int {
+	idx := make([]int, 0, len(p.Rules))
+	for i, r := range p.Rules {
+		if r.Type == SharingRuleRatio {
+			idx = append(idx, i)
+		}
+	}
+	frac := func(i int) int64 {
+		return int64(amount) * int64(p.Rules[i].RatioBp) % 10000
+	}
+	sort.SliceStable(idx, func(a, b int) bool {
+		return frac(idx[a]) > frac(idx[b])
+	})
+
+	spread := 0
+	for k := 0; k < residue && k < len(idx); k++ {
+		amounts[idx[k]]++
+		spread++
+	}
+	return spread
+}
+
+// PlanForOrder 按分账方案拆分已支付订单的金额
+// amount 本次分账总金额，为0时按订单成功金额全额分账
+func (p *SharingPlan) PlanForOrder(order *QueryOrderData, amount int) ([]SharingDetails, error) {
+	if order.TxnState != SUCCESS {
+		return nil, fmt.Errorf("订单状态 %s 不可分账", order.TxnState)
+	}
+	if amount == 0 {
+		amount = order.SuccAmt
+	}
+	if amount > order.SuccAmt {
+		return nil, fmt.Errorf("分账总金额 %d 超过订单成功金额 %d", amount, order.SuccAmt)
+	}
+	return p.Plan(amount)
+}
+
+// ValidateSharingDetails 校验分账明细
+// maxAmt 分账金额合计上限，单位：分
+func ValidateSharingDetails(details []SharingDetails, maxAmt int) error {
+	if len(details) == 0 {
+		return fmt.Errorf("分账明细不能为空")
+	}
+
+	total := 0
+	seen := make(map[string]bool, len(details))
+	for _, d := range details {
+		if d.SharingMerId == "" {
+			return fmt.Errorf("分账商户号不能为空")
+		}
+		if seen[d.SharingMerId] {
+			return fmt.Errorf("分账商户号重复: %s", d.SharingMerId)
+		}
+		seen[d.SharingMerId] = true
+		if d.SharingAmt <= 0 {
+			return fmt.Errorf("分账金额必须大于0: %s", d.SharingMerId)
+		}
+		total += d.SharingAmt
+	}
+	if total > maxAmt {
+		return fmt.Errorf("分账金额合计 %d 超过可分账金额 %d", total, maxAmt)
+	}
+	return nil
+}
+
+// SharingTotalAmt 分账明细金额合计
+func SharingTotalAmt(details []SharingDetails) int {
+	total := 0
+	for _, d := range details {
+		total += d.SharingAmt
+	}
+	return total
+}
+
+// roundRatio 计算 amount*ratioBp/10000 并按取整方式取整
+func roundRatio(amount, ratioBp int, mode RoundingMode) int {
+	product := int64(amount) * int64(ratioBp)
+	q := int(product / 10000)
+	r := product % 10000
+	if r == 0 {
+		return q
+	}
+
+	switch mode {
+	case RoundingCeil:
+		return q + 1
+	case RoundingHalfUp:
+		if r*2 >= 10000 {
+			return q + 1
+		}
+	case RoundingHalfEven:
+		if r*2 > 10000 || (r*2 == 10000 && q%2 == 1) {
+			return q + 1
+		}
+	}
+	return q
+}
+
+// SharingRecord 分账记录，用于跟踪原支付订单的已分账金额
+type SharingRecord struct {
+	OriginTradeNo    string           `json:"originTradeNo"`    // 原支付订单宝付交易号
+	OriginOutTradeNo string           `json:"originOutTradeNo"` // 原支付订单商户订单号
+	OutTradeNo       string           `json:"outTradeNo"`       // 商户分账订单号
+	TradeNo          string           `json:"tradeNo"`          // 宝付分账交易号
+	SharingDetails   []SharingDetails `json:"sharingDetails"`   // 请求的分账明细
+	SharingAmt       int              `json:"sharingAmt"`       // 请求的分账金额合计 单位：分
+	SuccAmt          int              `json:"succAmt"`          // 宝付返回的分账成功金额 单位：分，分账成功后才有值
+	TxnState         string           `json:"txnState"`         // 分账订单状态，为空表示结果未知
+	TxnTime          string           `json:"txnTime"`          // 分账交易时间
+}
+
+// Occupied 分账记录是否占用可分账金额，仅明确失败的分账不占用
+func (r *SharingRecord) Occupied() bool {
+	switch TxnState(r.TxnState) {
+	case PAY_ERROR, CLOSED:
+		return false
+	}
+	return r.TxnState != "FAIL"
+}
+
+// OccupiedAmt 分账记录占用的可分账金额，分账成功时以宝付返回的成功金额为准
+func (r *SharingRecord) OccupiedAmt() int {
+	if !r.Occupied() {
+		return 0
+	}
+	if r.TxnState == string(SUCCESS) && r.SuccAmt > 0 {
+		return r.SuccAmt
+	}
+	return r.SharingAmt
+}
+
+// Settled 分账记录是否已到达终态
+func (r *SharingRecord) Settled() bool {
+	return r.TxnState == string(SUCCESS) || !r.Occupied()
+}
+
+// SharingRefundRecord 分账退款记录，记录一笔退款从各分账商户扣回的金额
+type SharingRefundRecord struct {
+	OriginTradeNo     string              `json:"originTradeNo"`     // 原支付订单宝付交易号
+	OutTradeNo        string              `json:"outTradeNo"`        // 商户退款订单号
+	SharingRefundInfo []SharingRefundInfo `json:"sharingRefundInfo"` // 分账退款信息
+	RefundState       RefundState         `json:"refundState"`       // 退款状态，为空表示结果未知
+}
+
+// Occupied 分账退款记录是否占用分账商户的可扣回金额，仅明确失败的退款不占用
+func (r *SharingRefundRecord) Occupied() bool {
+	return r.RefundState != RefundStateRefundError
+}
+
+// SharingRefundMode 分账退款分摊方式
+type SharingRefundMode string
+
+const (
+	SharingRefundProportional SharingRefundMode = "PROPORTIONAL" // 按各商户分账金额比例分摊，尾差按最大余数法分配
+	SharingRefundPriority     SharingRefundMode = "PRIORITY"     // 按指定商户顺序依次扣回，直至满足退款金额
+)
+
+// AllocateSharingRefund 根据原分账明细计算分账退款信息，退款信息金额合计恰好等于 refundAmt
+// details 原分账明细（同一商户可出现多次，按商户合并）
+// refundAmt 需从分账商户扣回的金额，单位：分
+// priority 按优先级扣回时的商户顺序，未列出的商户按原分账明细顺序排在最后；按比例分摊时忽略
+func AllocateSharingRefund(details []SharingDetails, refundAmt int, mode SharingRefundMode, priority ...string) ([]SharingRefundInfo, error) {
+	if refundAmt <= 0 {
+		return nil, fmt.Errorf("分账退款金额必须大于0")
+	}
+
+	// 按商户合并分账金额，保持首次出现的顺序
+	merIds := make([]string, 0, len(details))
+	shared := make(map[string]int, len(details))
+	for _, d := range details {
+		if d.SharingAmt <= 0 {
+			continue
+		}
+		if _, ok := shared[d.SharingMerId]; !ok {
+			merIds = append(merIds, d.SharingMerId)
+		}
+		shared[d.SharingMerId] += d.SharingAmt
+	}
+
+	total := 0
+	for _, amt := range shared {
+		total += amt
+	}
+	if refundAmt > total {
+		return nil, fmt.Errorf("分账退款金额 %d 超过已分账金额 %d", refundAmt, total)
+	}
+
+	amounts := make(map[string]int, len(merIds))
+	switch mode {
+	case SharingRefundProportional:
+		allocated := 0
+		remainders := make([]int64, len(merIds))
+		for i, merId := range merIds {
+			product := int64(shared[merId]) * int64(refundAmt)
+			amounts[merId] = int(product / int64(total))
+			remainders[i] = product % int64(total)
+			allocated += amounts[merId]
+		}
+		// 尾差依次分给余数最大的商户，余数相同时按原分账明细顺序
+		for allocated < refundAmt {
+			best := -1
+			for i := range merIds {
+				if best < 0 || remainders[i] > remainders[best] {
+					best = i
+				}
+			}
+			amounts[merIds[best]]++
+			remainders[best] = -1
+			allocated++
+		}
+	case SharingRefundPriority:
+		order := make([]string, 0, len(merIds))
+		seen := make(map[string]bool, len(merIds))
+		for _, merId := range priority {
+			if _, ok := shared[merId]; !ok {
+				return nil, fmt.Errorf("商户 %s 不在原分账明细中", merId)
+			}
+			if !seen[merId] {
+				seen[merId] = true
+				order = append(order, merId)
+			}
+		}
+		for _, merId := range merIds {
+			if !seen[merId] {
+				order = append(order, merId)
+			}
+		}
+		left := refundAmt
+		for _, merId := range order {
+			amt := shared[merId]
+			if amt > left {
+				amt = left
+			}
+			amounts[merId] = amt
+			left -= amt
+		}
+	default:
+		return nil, fmt.Errorf("不支持的分账退款分摊方式: %s", mode)
+	}
+
+	infos := make([]SharingRefundInfo, 0, len(merIds))
+	for _, merId := range merIds {
+		if amounts[merId] > 0 {
+			infos = append(infos, SharingRefundInfo{
+				SharingMerId: merId,
+				SharingAmt:   amounts[merId],
+			})
+		}
+	}
+	return infos, nil
+}
+
+// ShareFinishRequest 完结分账请求，将原支付订单未分账金额解冻给主商户，完结后不可再分账
+type ShareFinishRequest struct {
+	MerId            string `json:"merId"`                      // 商户号
+	TerId            string `json:"terId"`                      // 终端号
+	OriginTradeNo    string `json:"originTradeNo,omitempty"`    // 原支付订单宝付交易号
+	OriginOutTradeNo string `json:"originOutTradeNo,omitempty"` // 原支付订单商户订单号
+	OutTradeNo       string `json:"outTradeNo"`                 // 商户完结分账订单号
+	TxnTime          string `json:"txnTime"`                    // 交易时间
+	NotifyUrl        string `json:"notifyUrl,omitempty"`        // 异步通知地址
+	Remark           string `json:"remark,omitempty"`           // 完结原因
+}
+
+// ShareFinishData 完结分账结果，用于下单、查询和异步通知
+type ShareFinishData struct {
+	MerID            string `json:"merId"`            // 商户号
+	TerID            string `json:"terId"`            // 终端号
+	OriginTradeNo    string `json:"originTradeNo"`    // 原支付订单宝付交易号
+	OriginOutTradeNo string `json:"originOutTradeNo"` // 原支付订单商户订单号
+	TradeNo          string `json:"tradeNo"`          // 宝付完结分账交易号
+	OutTradeNo       string `json:"outTradeNo"`       // 商户完结分账订单号
+	TxnState         string `json:"txnState"`         // 订单状态
+	UnfreezeAmt      int    `json:"unfreezeAmt"`      // 解冻给主商户的金额 单位：分
+	FinishTime       string `json:"finishTime"`       // 完成时间
+	ResultCode       string `json:"resultCode"`       // 业务结果 SUCCESS：成功 FAIL：失败
+	ErrCode          string `json:"errCode"`          // 错误代码
+	ErrMsg           string `json:"errMsg"`           // 错误描述
+}
+
+// ShareReturnRequest 分账回退请求，从分账接收方回退已分账金额给主商户
+type ShareReturnRequest struct {
+	MerId                 string `json:"merId"`                           // 商户号
+	TerId                 string `json:"terId"`                           // 终端号
+	OriginShareTradeNo    string `json:"originShareTradeNo,omitempty"`    // 原分账订单宝付交易号
+	OriginShareOutTradeNo string `json:"originShareOutTradeNo,omitempty"` // 原分账订单商户订单号
+	OutTradeNo            string `json:"outTradeNo"`                      // 商户分账回退订单号
+	ReturnMerId           string `json:"returnMerId"`                     // 回退方商户号（原分账接收方）
+	ReturnAmt             int    `json:"returnAmt"`                       // 回退金额 单位：分，不得大于该商户在原分账订单中的分账金额
+	TxnTime               string `json:"txnTime"`                         // 交易时间
+	NotifyUrl             string `json:"notifyUrl,omitempty"`             // 异步通知地址
+	Remark                string `json:"remark,omitempty"`                // 回退原因
+}
+
+// ShareReturnData 分账回退结果，用于下单、查询和异步通知
+type ShareReturnData struct {
+	MerID                 string `json:"merId"`                 // 商户号
+	TerID                 string `json:"terId"`                 // 终端号
+	OriginShareTradeNo    string `json:"originShareTradeNo"`    // 原分账订单宝付交易号
+	OriginShareOutTradeNo string `json:"originShareOutTradeNo"` // 原分账订单商户订单号
+	TradeNo               string `json:"tradeNo"`               // 宝付分账回退交易号
+	OutTradeNo            string `json:"outTradeNo"`            // 商户分账回退订单号
+	ReturnMerId           string `json:"returnMerId"`           // 回退方商户号
+	ReturnAmt             int    `json:"returnAmt"`             // 回退金额 单位：分
+	TxnState              string `json:"txnState"`              // 订单状态
+	FinishTime            string `json:"finishTime"`            // 完成时间
+	ResultCode            string `json:"resultCode"`            // 业务结果 SUCCESS：成功 FAIL：失败
+	ErrCode               string `json:"errCode"`               // 错误代码
+	ErrMsg                string `json:"errMsg"`                // 错误描述
+}
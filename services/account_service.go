@@ -1,9 +1,18 @@
 package services
 
 import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"os"
+	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/nicoaz/baofu-sdk/config"
 	"github.com/nicoaz/baofu-sdk/consts"
@@ -33,115 +42,94 @@ func (s *AccountService) getHost(method string) string {
 }
 
 // OpenAccount 开户接口
-func (s *AccountService) OpenAccount(req *models.AccountOpenRequest) (string, error) {
-	fmt.Println("==========================")
-	fmt.Println("宝付账簿个人/机构开户接口")
-	fmt.Println("==========================")
-
-	// 构建Header参数
-	headerPost := make(map[string]string)
-	headerPost["memberId"] = s.config.MerchantID
-	headerPost["terminalId"] = s.config.TerminalID
-	headerPost["serviceTp"] = consts.MethodOpenAccount
-	headerPost["verifyType"] = "1" // 加密方式目前只有1种，请填：1
-
-	// 构建请求数据
-	contentData := make(map[string]interface{})
-	contentData["header"] = headerPost
+// 支持个人(accType 1)、企业和个体户(accType 2)开户，个体户绑定法人对私结算卡时须传持卡人姓名
+func (s *AccountService) OpenAccount(req *models.AccountOpenRequest) (*models.OpenAccountResponse, error) {
+	if req.AccType == "" {
+		req.AccType = models.AccTypeMerchant
+	}
+	if req.Version == "" {
+		req.Version = "4.1.0"
+	}
+	if req.BusinessType == "" {
+		req.BusinessType = "BCT2.0"
+	}
+	if req.TransSerialNo == "" {
+		req.TransSerialNo = utils.GetTransid("TSN")
+	}
+	if req.AccType == models.AccTypePersonal && req.CertificateType == "" {
+		req.CertificateType = "ID"
+	}
+	if err := validateOpenAccount(req); err != nil {
+		return nil, err
+	}
 
 	// 构建Body数据
 	bodyData := make(map[string]interface{})
-	bodyData["version"] = "4.1.0"         // 版本号
-	bodyData["accType"] = "2"             // 账户类型:1个人,2商户
-	bodyData["noticeUrl"] = req.NotifyUrl // 异步通知地址
-	bodyData["businessType"] = "BCT2.0"   // 宝财通2.0
+	bodyData["version"] = req.Version           // 版本号
+	bodyData["accType"] = req.AccType           // 账户类型:1个人,2商户
+	bodyData["noticeUrl"] = req.NotifyUrl       // 异步通知地址
+	bodyData["businessType"] = req.BusinessType // 业务类型
 
 	// 构建账户信息
 	accInfo := make(map[string]string)
-
-	// 商户账户信息
-	accInfo["transSerialNo"] = utils.GetTransid("TSN") // 请求流水号
-	accInfo["loginNo"] = req.LoginNo                   // 登录号(用户ID)建议长度8位以上 唯一
-	accInfo["email"] = req.Email                       // 邮箱
-	accInfo["selfEmployed"] = req.SelfEmployed         // 是否个体户 默认为false
-	accInfo["customerName"] = req.CustomerName         // 商户名称（营业执照上的名称）
-	//accInfo["aliasName"] = ""                          // 商户名称别名  (选传)
-	accInfo["certificateNo"] = req.CertificateNo         // 证件号码
-	accInfo["certificateType"] = req.CertificateType     // 证件类型 营业执照:LICENSE
-	accInfo["corporateName"] = req.CorporateName         // 法人姓名
-	accInfo["corporateCertType"] = req.CorporateCertType // 法人证件类型  身份证:ID
-	accInfo["corporateCertId"] = req.CorporateCertId     // 法人身份证号码
-	accInfo["corporateMobile"] = req.CorporateMobile     // 法人手机号
-	accInfo["industryId"] = req.IndustryId               // 所属行业
-	//accInfo["contactName"] = ""                        // 联系人姓名
-	//accInfo["contactMobile"] = ""                      // 联系人手机号
-	accInfo["cardNo"] = req.CardNo                           // 卡号
-	accInfo["bankName"] = req.BankName                       // 银行名称
-	accInfo["depositBankProvince"] = req.DepositBankProvince // 开户行省份
-	accInfo["depositBankCity"] = req.DepositBankCity         // 开户行城市
-	accInfo["depositBankName"] = req.DeositBankName          // 开户支行名称
-	accInfo["registerCapital"] = req.RegisterCapital         // 注册资本
-	//accInfo["cardUserName"] = ""                       // 持卡人姓名  (个体绑法人对私必传)
-	// 设置平台相关信息
-	accInfo["platformNo"] = ""                 // 平台号(主商户号) (代理模式必传)
-	accInfo["platformTerminalId"] = ""         // 终端号(代理模式必传)
-	accInfo["qualificationTransSerialNo"] = "" // 资质文件流水,businessType为宝财通2.0非必填
-
-	// 将账户信息添加到请求体中
-	bodyData["accInfo"] = accInfo
-	contentData["body"] = bodyData
-
-	// 将请求数据转换为JSON
-	jsonObject, err := json.Marshal(contentData)
-	if err != nil {
-		return "", err
+	accInfo["transSerialNo"] = req.TransSerialNo // 请求流水号
+	accInfo["loginNo"] = req.LoginNo             // 登录号(用户ID)建议长度8位以上 唯一
+	accInfo["email"] = req.Email                 // 邮箱
+	accInfo["customerName"] = req.CustomerName   // 商户名称（营业执照上的名称）/ 个人姓名
+	accInfo["certificateNo"] = req.CertificateNo // 证件号码
+	accInfo["cardNo"] = req.CardNo               // 卡号
+	accInfo["bankName"] = req.BankName           // 银行名称
+
+	if req.AccType == models.AccTypePersonal {
+		// 个人账户信息
+		accInfo["certificateType"] = req.CertificateType // 证件类型 身份证:ID
+		accInfo["mobileNo"] = req.Mobile                 // 手机号
+	} else {
+		// 商户账户信息
+		accInfo["selfEmployed"] = req.SelfEmployed               // 是否个体户 默认为false
+		accInfo["certificateType"] = req.CertificateType         // 证件类型 营业执照:LICENSE
+		accInfo["corporateName"] = req.CorporateName             // 法人姓名
+		accInfo["corporateCertType"] = req.CorporateCertType     // 法人证件类型  身份证:ID
+		accInfo["corporateCertId"] = req.CorporateCertId         // 法人身份证号码
+		accInfo["corporateMobile"] = req.CorporateMobile         // 法人手机号
+		accInfo["industryId"] = req.IndustryId                   // 所属行业
+		accInfo["depositBankProvince"] = req.DepositBankProvince // 开户行省份
+		accInfo["depositBankCity"] = req.DepositBankCity         // 开户行城市
+		accInfo["depositBankName"] = req.DeositBankName          // 开户支行名称
+		accInfo["registerCapital"] = req.RegisterCapital         // 注册资本
 	}
-	fmt.Println("JSON：", string(jsonObject))
 
-	// 加密请求数据
-	dataContent, err := utils.EncryptByPFXFile(string(jsonObject), s.config.PrivateKey)
-	if err != nil {
-		return "", err
+	// 选传字段，未填写时不上送
+	optional := map[string]string{
+		"aliasName":     req.AliasName,     // 商户名称别名
+		"contactName":   req.ContactName,   // 联系人姓名
+		"contactMobile": req.ContactMobile, // 联系人手机号
+		"cardUserName":  req.CardUserName,  // 持卡人姓名 (个体绑法人对私必传)
 	}
-	headerPost["content"] = dataContent
-
-	// 发送请求
-	response, err := utils.Post(headerPost, s.getHost(consts.MethodOpenAccount), "json")
-	if err != nil {
-		return "", err
+	for k, v := range optional {
+		if v != "" {
+			accInfo[k] = v
+		}
 	}
-	fmt.Println("返回：", string(response))
 
-	if len(response) == 0 {
-		return "", fmt.Errorf("返回异常！")
-	}
+	// 设置平台相关信息
+	accInfo["platformNo"] = req.PlatformNo                                 // 平台号(主商户号) (代理模式必传)
+	accInfo["platformTerminalId"] = req.PlatformTerminalId                 // 终端号(代理模式必传)
+	accInfo["qualificationTransSerialNo"] = req.QualificationTransSerialNo // 资质文件流水,businessType为宝财通2.0非必填
 
-	// 解密返回数据
-	rPostString, err := utils.DecryptByCERFile(string(response), s.config.BFPublicKey, s.config.BFPublicKeyPem)
-	if err != nil {
-		return "", err
-	}
-	fmt.Println("解密明文：", rPostString)
+	// 将账户信息添加到请求体中
+	bodyData["accInfo"] = accInfo
 
-	return rPostString, nil
+	var openAccountResponse models.OpenAccountResponse
+	if err := s.request(consts.MethodOpenAccount, bodyData, &openAccountResponse); err != nil {
+		return nil, err
+	}
+	body := openAccountResponse.Body
+	return &openAccountResponse, models.NewAccountError(openAccountResponse.Header, body.RetCode, body.ErrorCode, body.ErrorMsg)
 }
 
-// 开户查询接口
-func (s *AccountService) OpenAccountQuery(req *models.OpenAccountQueryRequest) (string, error) {
-	fmt.Println("==========================")
-	fmt.Println("宝付账簿开户查询接口")
-	fmt.Println("==========================")
-
-	// 构建Header参数
-	headerPost := make(map[string]string)
-	headerPost["memberId"] = s.config.MerchantID
-	headerPost["terminalId"] = s.config.TerminalID
-	headerPost["serviceTp"] = consts.MethodOpenAccountQuery
-	headerPost["verifyType"] = "1" // 加密方式目前只有1种，请填：1
-
-	// 构建请求数据
-	contentData := make(map[string]interface{})
-	contentData["header"] = headerPost
+// OpenAccountQuery 开户查询接口
+func (s *AccountService) OpenAccountQuery(req *models.OpenAccountQueryRequest) (*models.OpenAccountQueryResponse, error) {
 
 	// 构建Body数据
 	bodyData := make(map[string]interface{})
@@ -152,41 +140,12 @@ func (s *AccountService) OpenAccountQuery(req *models.OpenAccountQueryRequest) (
 	bodyData["loginNo"] = req.LoginNo
 	bodyData["accType"] = req.AccType
 
-	contentData["body"] = bodyData
-
-	// 将请求数据转换为JSON
-	jsonObject, err := json.Marshal(contentData)
-	if err != nil {
-		return "", err
-	}
-	fmt.Println("JSON：", string(jsonObject))
-
-	// 加密请求数据
-	dataContent, err := utils.EncryptByPFXFile(string(jsonObject), s.config.PrivateKey)
-	if err != nil {
-		return "", err
-	}
-	headerPost["content"] = dataContent
-
-	// 发送请求
-	response, err := utils.Post(headerPost, s.getHost(consts.MethodOpenAccountQuery), "json")
-	if err != nil {
-		return "", err
-	}
-	fmt.Println("返回：", string(response))
-
-	if len(response) == 0 {
-		return "", fmt.Errorf("返回异常！")
-	}
-
-	// 解密返回数据
-	rPostString, err := utils.DecryptByCERFile(string(response), s.config.BFPublicKey, s.config.BFPublicKeyPem)
-	if err != nil {
-		return "", err
+	var openAccountQueryResponse models.OpenAccountQueryResponse
+	if err := s.request(consts.MethodOpenAccountQuery, bodyData, &openAccountQueryResponse); err != nil {
+		return nil, err
 	}
-	fmt.Println("解密明文：", rPostString)
-
-	return rPostString, nil
+	body := openAccountQueryResponse.Body
+	return &openAccountQueryResponse, models.NewAccountError(openAccountQueryResponse.Header, body.RetCode, body.ErrorCode, body.ErrorMsg)
 }
 
 // BalanceQuery 余额查询接口
@@ -312,6 +271,28 @@ func (s *AccountService) Transfer(req *models.TransferRequest) (*models.Transfer
 	return &transferResponse, nil
 }
 
+// TransferQuery 账户间转账查询接口，转账请求超时等结果未知时用于确认资金是否已划转
+func (s *AccountService) TransferQuery(req *models.TransferQueryRequest) (*models.TransferQueryResponse, error) {
+	if req.TransSerialNo == "" {
+		return nil, fmt.Errorf("转账请求流水号不能为空")
+	}
+
+	// 构建Body数据
+	bodyData := make(map[string]interface{})
+	bodyData["version"] = "4.0.0"
+	bodyData["transSerialNo"] = req.TransSerialNo
+	if req.TradeTime != "" {
+		bodyData["tradeTime"] = req.TradeTime
+	}
+
+	var transferQueryResponse models.TransferQueryResponse
+	if err := s.request(consts.MethodTransferQuery, bodyData, &transferQueryResponse); err != nil {
+		return nil, err
+	}
+	body := transferQueryResponse.Body
+	return &transferQueryResponse, models.NewAccountError(transferQueryResponse.Header, body.RetCode, body.ErrorCode, body.ErrorMsg)
+}
+
 // Withdraw 提现接口
 func (s *AccountService) Withdraw(req *models.WithdrawRequest) (*models.WithdrawResponse, error) {
 
@@ -436,3 +417,819 @@ func (s *AccountService) WithdrawQuery(req *models.WithdrawQueryRequest) (*model
 	}
 	return &withdrawQueryResponse, nil
 }
+
+// ModifyAccount 账户信息修改接口，可修改联系人信息和结算银行卡
+func (s *AccountService) ModifyAccount(req *models.AccountModifyRequest) (*models.AccountModifyResponse, error) {
+	if req.ContractNo == "" {
+		return nil, fmt.Errorf("客户账户号不能为空")
+	}
+	if req.TransSerialNo == "" {
+		req.TransSerialNo = utils.GetTransid("TSN")
+	}
+	if req.Version == "" {
+		req.Version = "4.0.0"
+	}
+	// 构建Body数据，未填写的字段不修改
+	bodyData := make(map[string]interface{})
+	bodyData["version"] = req.Version
+	bodyData["transSerialNo"] = req.TransSerialNo
+	bodyData["contractNo"] = req.ContractNo
+	bodyData["acctType"] = req.AcctType
+	optional := map[string]string{
+		"noticeUrl":       req.NoticeUrl,
+		"acctName":        req.AcctName,
+		"legalPersonName": req.LegalPersonName,
+		"bizLicenseCode":  req.BizLicenseCode,
+		"phone":           req.Phone,
+		"email":           req.Email,
+		"idCardType":      req.IdCardType,
+		"idCardCode":      req.IdCardCode,
+		"bankCode":        req.BankCode,
+		"cardType":        req.CardType,
+		"bankCardNo":      req.BankCardNo,
+		"expireDate":      req.ExpireDate,
+		"cvv":             req.CVV,
+	}
+	for k, v := range optional {
+		if v != "" {
+			bodyData[k] = v
+		}
+	}
+
+	return s.modifyRequest(consts.MethodAccountModify, bodyData)
+}
+
+// ModifyAccountQuery 账户信息修改查询接口
+func (s *AccountService) ModifyAccountQuery(req *models.AccountModifyQueryRequest) (*models.AccountModifyResponse, error) {
+
+	// 构建Body数据
+	bodyData := make(map[string]interface{})
+	bodyData["version"] = "4.0.0"
+	bodyData["contractNo"] = req.ContractNo
+	bodyData["transSerialNo"] = req.TransSerialNo
+
+	return s.modifyRequest(consts.MethodAccountModifyQuery, bodyData)
+}
+
+func (s *AccountService) modifyRequest(serviceTp string, bodyData map[string]interface{}) (*models.AccountModifyResponse, error) {
+	var modifyResponse models.AccountModifyResponse
+	if err := s.request(serviceTp, bodyData, &modifyResponse); err != nil {
+		return nil, err
+	}
+	body := modifyResponse.Body
+	return &modifyResponse, models.NewAccountError(modifyResponse.Header, body.RetCode, body.ErrorCode, body.ErrorMsg)
+}
+
+// BindCard 绑定结算卡接口，对私卡需异步验证，结果通过 ParseCardNotify 解析
+func (s *AccountService) BindCard(req *models.BindCardRequest) (*models.CardResponse, error) {
+	if req.ContractNo == "" || req.Card.CardNo == "" {
+		return nil, fmt.Errorf("客户账户号和银行卡号不能为空")
+	}
+	if req.TransSerialNo == "" {
+		req.TransSerialNo = utils.GetTransid("TSN")
+	}
+
+	// 构建Body数据
+	bodyData := make(map[string]interface{})
+	bodyData["version"] = "4.0.0"
+	bodyData["contractNo"] = req.ContractNo
+	bodyData["transSerialNo"] = req.TransSerialNo
+	bodyData["noticeUrl"] = req.NoticeUrl
+	bodyData["cardInfo"] = req.Card
+
+	return s.cardRequest(consts.MethodBindCard, bodyData)
+}
+
+// UnbindCard 解绑结算卡接口
+func (s *AccountService) UnbindCard(req *models.UnbindCardRequest) (*models.CardResponse, error) {
+	if req.ContractNo == "" || req.CardNo == "" {
+		return nil, fmt.Errorf("客户账户号和银行卡号不能为空")
+	}
+	if req.TransSerialNo == "" {
+		req.TransSerialNo = utils.GetTransid("TSN")
+	}
+
+	// 构建Body数据
+	bodyData := make(map[string]interface{})
+	bodyData["version"] = "4.0.0"
+	bodyData["contractNo"] = req.ContractNo
+	bodyData["transSerialNo"] = req.TransSerialNo
+	bodyData["cardNo"] = req.CardNo
+
+	return s.cardRequest(consts.MethodUnbindCard, bodyData)
+}
+
+// ReplaceCard 更换结算卡接口，新卡验证通过后原卡自动解绑
+func (s *AccountService) ReplaceCard(req *models.ReplaceCardRequest) (*models.CardResponse, error) {
+	if req.ContractNo == "" || req.OldCardNo == "" || req.Card.CardNo == "" {
+		return nil, fmt.Errorf("客户账户号、原银行卡号和新银行卡号不能为空")
+	}
+	if req.OldCardNo == req.Card.CardNo {
+		return nil, fmt.Errorf("新银行卡号不能与原银行卡号相同")
+	}
+	if req.TransSerialNo == "" {
+		req.TransSerialNo = utils.GetTransid("TSN")
+	}
+
+	// 构建Body数据
+	bodyData := make(map[string]interface{})
+	bodyData["version"] = "4.0.0"
+	bodyData["contractNo"] = req.ContractNo
+	bodyData["transSerialNo"] = req.TransSerialNo
+	bodyData["noticeUrl"] = req.NoticeUrl
+	bodyData["oldCardNo"] = req.OldCardNo
+	bodyData["cardInfo"] = req.Card
+
+	return s.cardRequest(consts.MethodReplaceCard, bodyData)
+}
+
+// CardQuery 查询账户已绑定的结算卡
+func (s *AccountService) CardQuery(contractNo string) (*models.CardQueryResponse, error) {
+	if contractNo == "" {
+		return nil, fmt.Errorf("客户账户号不能为空")
+	}
+
+	// 构建Body数据
+	bodyData := make(map[string]interface{})
+	bodyData["version"] = "4.0.0"
+	bodyData["contractNo"] = contractNo
+
+	var cardQueryResponse models.CardQueryResponse
+	if err := s.request(consts.MethodCardQuery, bodyData, &cardQueryResponse); err != nil {
+		return nil, err
+	}
+	body := cardQueryResponse.Body
+	return &cardQueryResponse, models.NewAccountError(cardQueryResponse.Header, body.RetCode, body.ErrorCode, body.ErrorMsg)
+}
+
+// ParseCardNotify 解密并解析结算卡验证结果异步通知
+// content 通知中的加密报文
+func (s *AccountService) ParseCardNotify(content string) (*models.CardNotify, error) {
+	var notify models.CardNotify
+	if err := s.parseNotify(content, &notify); err != nil {
+		return nil, err
+	}
+	return &notify, nil
+}
+
+func (s *AccountService) cardRequest(serviceTp string, bodyData map[string]interface{}) (*models.CardResponse, error) {
+	var cardResponse models.CardResponse
+	if err := s.request(serviceTp, bodyData, &cardResponse); err != nil {
+		return nil, err
+	}
+	body := cardResponse.Body
+	return &cardResponse, models.NewAccountError(cardResponse.Header, body.RetCode, body.ErrorCode, body.ErrorMsg)
+}
+
+// parseNotify 解密账户异步通知报文并解析到 v
+// 通知报文可能为 {"header":{},"body":{}} 结构，此时解析 body 部分
+func (s *AccountService) parseNotify(content string, v interface{}) error {
+	if content == "" {
+		return fmt.Errorf("通知内容为空")
+	}
+
+	plain, err := utils.DecryptByCERFile(content, s.config.BFPublicKey, s.config.BFPublicKeyPem)
+	if err != nil {
+		return fmt.Errorf("通知解密失败: %v", err)
+	}
+
+	var envelope struct {
+		Body json.RawMessage `json:"body"`
+	}
+	if err := json.Unmarshal([]byte(plain), &envelope); err == nil && len(envelope.Body) > 0 {
+		plain = string(envelope.Body)
+	}
+
+	if err := json.Unmarshal([]byte(plain), v); err != nil {
+		return fmt.Errorf("解析通知失败: %v", err)
+	}
+	return nil
+}
+
+// Freeze 资金冻结接口，冻结账户可用余额（如纠纷处理期间暂扣商户资金）
+func (s *AccountService) Freeze(req *models.FreezeRequest) (*models.FreezeResponse, error) {
+	if req.ContractNo == "" || req.TransSerialNo == "" {
+		return nil, fmt.Errorf("客户账户号和冻结请求流水号不能为空")
+	}
+	if req.DealAmount <= 0 {
+		return nil, fmt.Errorf("冻结金额必须大于0")
+	}
+
+	// 构建Body数据
+	bodyData := make(map[string]interface{})
+	bodyData["version"] = "4.0.0"
+	bodyData["contractNo"] = req.ContractNo
+	bodyData["transSerialNo"] = req.TransSerialNo
+	bodyData["dealAmount"] = req.DealAmount
+	bodyData["remark"] = req.Remark
+
+	return s.freezeRequest(consts.MethodFreeze, bodyData)
+}
+
+// Unfreeze 资金解冻接口，按原冻结流水全部或部分解冻
+func (s *AccountService) Unfreeze(req *models.UnfreezeRequest) (*models.FreezeResponse, error) {
+	if req.ContractNo == "" || req.TransSerialNo == "" || req.OrigTransSerialNo == "" {
+		return nil, fmt.Errorf("客户账户号、解冻请求流水号和原冻结请求流水号不能为空")
+	}
+	if req.DealAmount <= 0 {
+		return nil, fmt.Errorf("解冻金额必须大于0")
+	}
+
+	// 构建Body数据
+	bodyData := make(map[string]interface{})
+	bodyData["version"] = "4.0.0"
+	bodyData["contractNo"] = req.ContractNo
+	bodyData["transSerialNo"] = req.TransSerialNo
+	bodyData["origTransSerialNo"] = req.OrigTransSerialNo
+	bodyData["dealAmount"] = req.DealAmount
+	bodyData["remark"] = req.Remark
+
+	return s.freezeRequest(consts.MethodUnfreeze, bodyData)
+}
+
+// FreezeQuery 冻结/解冻查询接口
+func (s *AccountService) FreezeQuery(req *models.FreezeQueryRequest) (*models.FreezeResponse, error) {
+	if req.TransSerialNo == "" {
+		return nil, fmt.Errorf("请求流水号不能为空")
+	}
+
+	// 构建Body数据
+	bodyData := make(map[string]interface{})
+	bodyData["version"] = "4.0.0"
+	bodyData["transSerialNo"] = req.TransSerialNo
+	if req.TradeTime != "" {
+		bodyData["tradeTime"] = req.TradeTime
+	}
+
+	return s.freezeRequest(consts.MethodFreezeQuery, bodyData)
+}
+
+func (s *AccountService) freezeRequest(serviceTp string, bodyData map[string]interface{}) (*models.FreezeResponse, error) {
+	var freezeResponse models.FreezeResponse
+	if err := s.request(serviceTp, bodyData, &freezeResponse); err != nil {
+		return nil, err
+	}
+	body := freezeResponse.Body
+	return &freezeResponse, models.NewAccountError(freezeResponse.Header, body.RetCode, body.ErrorCode, body.ErrorMsg)
+}
+
+// AccountDetailQuery 账户明细（流水）分页查询接口
+func (s *AccountService) AccountDetailQuery(req *models.AccountDetailQueryRequest) (*models.AccountDetailQueryResponse, error) {
+	if req.ContractNo == "" {
+		return nil, fmt.Errorf("客户账户号不能为空")
+	}
+	if req.StartDate == "" || req.EndDate == "" {
+		return nil, fmt.Errorf("查询开始日期和结束日期不能为空")
+	}
+	if err := validateDetailDateRange(req.StartDate, req.EndDate); err != nil {
+		return nil, err
+	}
+	normalizeDetailPage(req)
+
+	// 构建Body数据
+	bodyData := make(map[string]interface{})
+	bodyData["version"] = "4.0.0"
+	bodyData["contractNo"] = req.ContractNo
+	bodyData["startDate"] = req.StartDate
+	bodyData["endDate"] = req.EndDate
+	bodyData["pageNo"] = req.PageNo
+	bodyData["pageSize"] = req.PageSize
+
+	var detailResponse models.AccountDetailQueryResponse
+	if err := s.request(consts.MethodAccountDetailQuery, bodyData, &detailResponse); err != nil {
+		return nil, err
+	}
+	body := detailResponse.Body
+	return &detailResponse, models.NewAccountError(detailResponse.Header, body.RetCode, body.ErrorCode, body.ErrorMsg)
+}
+
+// accountDetailMaxDays 账户明细单次查询的最大日期跨度
+const accountDetailMaxDays = 31
+
+// validateDetailDateRange 校验明细查询日期格式及跨度
+func validateDetailDateRange(startDate, endDate string) error {
+	start, err := time.Parse("20060102", startDate)
+	if err != nil {
+		return fmt.Errorf("开始日期格式错误，应为yyyyMMdd: %s", startDate)
+	}
+	end, err := time.Parse("20060102", endDate)
+	if err != nil {
+		return fmt.Errorf("结束日期格式错误，应为yyyyMMdd: %s", endDate)
+	}
+	if end.Before(start) {
+		return fmt.Errorf("结束日期不能早于开始日期")
+	}
+	// 起止日期均包含在内，如 20240101 至 20240131 为31天
+	if days := int(end.Sub(start).Hours()/24) + 1; days > accountDetailMaxDays {
+		return fmt.Errorf("查询日期跨度不能超过%d天，当前为%d天", accountDetailMaxDays, days)
+	}
+	return nil
+}
+
+// normalizeDetailPage 补全页码并将每页条数限制在1~100之间
+func normalizeDetailPage(req *models.AccountDetailQueryRequest) {
+	if req.PageNo <= 0 {
+		req.PageNo = 1
+	}
+	if req.PageSize <= 0 {
+		req.PageSize = 20
+	}
+	if req.PageSize > 100 {
+		req.PageSize = 100
+	}
+}
+
+// AccountDetailIterator 账户明细迭代器，自动翻页遍历查询区间内的全部明细
+type AccountDetailIterator struct {
+	service *AccountService
+	req     models.AccountDetailQueryRequest
+	page    []models.AccountDetail
+	index   int
+	fetched int
+	total   int
+	done    bool
+	err     error
+}
+
+// AccountDetails 创建账户明细迭代器
+//
+//	it := s.AccountDetails(req)
+//	for it.Next() {
+//		detail := it.Detail()
+//	}
+//	if err := it.Err(); err != nil {}
+func (s *AccountService) AccountDetails(req *models.AccountDetailQueryRequest) *AccountDetailIterator {
+	it := &AccountDetailIterator{service: s, req: *req}
+	normalizeDetailPage(&it.req)
+	return it
+}
+
+// Next 移动到下一条明细，没有更多明细或查询出错时返回false
+func (it *AccountDetailIterator) Next() bool {
+	if it.err != nil {
+		return false
+	}
+	it.index++
+	for it.index >= len(it.page) {
+		if it.done {
+			return false
+		}
+		resp, err := it.service.AccountDetailQuery(&it.req)
+		if err != nil {
+			it.err = err
+			return false
+		}
+		it.page = resp.Body.Details
+		it.index = 0
+		it.fetched += len(it.page)
+		it.total = resp.Body.TotalCount
+		it.req.PageNo++
+		// 已知总条数时以总条数为准，否则以空页或不满一页作为结束
+		if len(it.page) == 0 {
+			it.done = true
+		} else if it.total > 0 {
+			it.done = it.fetched >= it.total
+		} else {
+			it.done = len(it.page) < it.req.PageSize
+		}
+	}
+	return true
+}
+
+// Detail 返回当前明细
+func (it *AccountDetailIterator) Detail() models.AccountDetail {
+	return it.page[it.index]
+}
+
+// Total 返回明细总条数（首页查询后有效）
+func (it *AccountDetailIterator) Total() int {
+	return it.total
+}
+
+// Err 返回遍历过程中的错误
+func (it *AccountDetailIterator) Err() error {
+	return it.err
+}
+
+// CloseAccount 销户接口
+// 销户前先查询余额，可用余额、在途余额或冻结金额不为0时拒绝销户
+func (s *AccountService) CloseAccount(req *models.CloseAccountRequest) (*models.CloseAccountResponse, error) {
+	if req.ContractNo == "" {
+		return nil, fmt.Errorf("客户账户号不能为空")
+	}
+	if req.TransSerialNo == "" {
+		req.TransSerialNo = utils.GetTransid("TSN")
+	}
+
+	// 销户前余额检查
+	balance, err := s.BalanceQuery(&models.BalanceQueryRequest{
+		AcctType:   req.AccType,
+		ContractNo: req.ContractNo,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("销户前余额查询失败: %v", err)
+	}
+	bal := balance.Body
+	if err := models.NewAccountError(balance.Header, bal.RetCode, bal.ErrorCode, bal.ErrorMsg); err != nil {
+		return nil, fmt.Errorf("销户前余额查询失败: %v", err)
+	}
+	frozenBal := bal.CurrBal - bal.AvailableBal - bal.PendingBal
+	if yuanToFen(bal.AvailableBal) != 0 || yuanToFen(bal.PendingBal) != 0 || yuanToFen(frozenBal) != 0 {
+		return nil, fmt.Errorf("账户仍有资金，不能销户: 可用余额 %.2f 元，在途余额 %.2f 元，冻结金额 %.2f 元",
+			bal.AvailableBal, bal.PendingBal, frozenBal)
+	}
+
+	// 构建Body数据
+	bodyData := make(map[string]interface{})
+	bodyData["version"] = "4.0.0"
+	bodyData["contractNo"] = req.ContractNo
+	bodyData["accType"] = req.AccType
+	bodyData["transSerialNo"] = req.TransSerialNo
+	bodyData["noticeUrl"] = req.NoticeUrl
+	bodyData["reason"] = req.Reason
+
+	return s.closeAccountRequest(consts.MethodCloseAccount, bodyData)
+}
+
+// CloseAccountQuery 销户查询接口
+func (s *AccountService) CloseAccountQuery(req *models.CloseAccountQueryRequest) (*models.CloseAccountResponse, error) {
+	if req.ContractNo == "" && req.TransSerialNo == "" {
+		return nil, fmt.Errorf("客户账户号和销户请求流水号不能同时为空")
+	}
+
+	// 构建Body数据
+	bodyData := make(map[string]interface{})
+	bodyData["version"] = "4.0.0"
+	bodyData["contractNo"] = req.ContractNo
+	bodyData["transSerialNo"] = req.TransSerialNo
+
+	return s.closeAccountRequest(consts.MethodCloseAccountQuery, bodyData)
+}
+
+func (s *AccountService) closeAccountRequest(serviceTp string, bodyData map[string]interface{}) (*models.CloseAccountResponse, error) {
+	var closeResponse models.CloseAccountResponse
+	if err := s.request(serviceTp, bodyData, &closeResponse); err != nil {
+		return nil, err
+	}
+	body := closeResponse.Body
+	return &closeResponse, models.NewAccountError(closeResponse.Header, body.RetCode, body.ErrorCode, body.ErrorMsg)
+}
+
+// Recharge 账户充值接口，支持银行卡充值和聚合支付订单充值
+func (s *AccountService) Recharge(req *models.RechargeRequest) (*models.RechargeResponse, error) {
+	if req.ContractNo == "" || req.TransSerialNo == "" {
+		return nil, fmt.Errorf("客户账户号和充值请求流水号不能为空")
+	}
+	if req.DealAmount <= 0 {
+		return nil, fmt.Errorf("充值金额必须大于0")
+	}
+
+	// 构建Body数据
+	bodyData := make(map[string]interface{})
+	bodyData["version"] = "4.0.0"
+	bodyData["contractNo"] = req.ContractNo
+	bodyData["transSerialNo"] = req.TransSerialNo
+	bodyData["rechargeType"] = req.RechargeType
+	bodyData["dealAmount"] = req.DealAmount
+	bodyData["noticeUrl"] = req.NoticeUrl
+	bodyData["reqReserved"] = req.ReqReserved
+
+	switch req.RechargeType {
+	case models.RechargeTypeCard:
+		if req.CardNo == "" {
+			return nil, fmt.Errorf("银行卡充值扣款卡号不能为空")
+		}
+		bodyData["cardNo"] = req.CardNo
+	case models.RechargeTypeJuhe:
+		if req.OrigTradeNo == "" {
+			return nil, fmt.Errorf("聚合支付充值宝付交易号不能为空")
+		}
+		bodyData["origTradeNo"] = req.OrigTradeNo
+	default:
+		return nil, fmt.Errorf("不支持的充值方式: %s", req.RechargeType)
+	}
+
+	return s.rechargeRequest(consts.MethodRecharge, bodyData)
+}
+
+// RechargeQuery 充值查询接口
+func (s *AccountService) RechargeQuery(req *models.RechargeQueryRequest) (*models.RechargeResponse, error) {
+	if req.TransSerialNo == "" {
+		return nil, fmt.Errorf("充值请求流水号不能为空")
+	}
+
+	// 构建Body数据
+	bodyData := make(map[string]interface{})
+	bodyData["version"] = "4.0.0"
+	bodyData["transSerialNo"] = req.TransSerialNo
+	if req.TradeTime != "" {
+		bodyData["tradeTime"] = req.TradeTime
+	}
+
+	return s.rechargeRequest(consts.MethodRechargeQuery, bodyData)
+}
+
+// ParseRechargeNotify 解密并解析充值结果异步通知
+// content 通知中的加密报文
+func (s *AccountService) ParseRechargeNotify(content string) (*models.RechargeNotify, error) {
+	var notify models.RechargeNotify
+	if err := s.parseNotify(content, &notify); err != nil {
+		return nil, err
+	}
+	return &notify, nil
+}
+
+func (s *AccountService) rechargeRequest(serviceTp string, bodyData map[string]interface{}) (*models.RechargeResponse, error) {
+	var rechargeResponse models.RechargeResponse
+	if err := s.request(serviceTp, bodyData, &rechargeResponse); err != nil {
+		return nil, err
+	}
+	body := rechargeResponse.Body
+	return &rechargeResponse, models.NewAccountError(rechargeResponse.Header, body.RetCode, body.ErrorCode, body.ErrorMsg)
+}
+
+// yuanToFen 将以元为单位的金额换算为分，消除浮点误差
+func yuanToFen(yuan float64) int64 {
+	return int64(math.Round(yuan * 100))
+}
+
+// 资质文件限制
+const (
+	qualificationMaxFileSize = 5 << 20 // 单个文件不超过5MB
+	qualificationMaxFiles    = 10      // 单次最多上传10个文件
+)
+
+// qualificationFormats 资质文件允许的扩展名及对应的文件内容类型
+var qualificationFormats = map[string]string{
+	".jpg":  "image/jpeg",
+	".jpeg": "image/jpeg",
+	".png":  "image/png",
+	".pdf":  "application/pdf",
+}
+
+// QualificationFileFromPath 从本地路径读取资质文件，超过大小限制的文件不会读入内存
+func QualificationFileFromPath(fileType models.QualificationType, path string) (*models.QualificationFile, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取资质文件失败: %v", err)
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("读取资质文件失败: %v", err)
+	}
+	if info.Size() > qualificationMaxFileSize {
+		return nil, fmt.Errorf("资质文件 %s 超过 %dMB", filepath.Base(path), qualificationMaxFileSize>>20)
+	}
+	// 多读1字节，防止文件在读取期间变大
+	content, err := io.ReadAll(io.LimitReader(f, qualificationMaxFileSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("读取资质文件失败: %v", err)
+	}
+	if len(content) > qualificationMaxFileSize {
+		return nil, fmt.Errorf("资质文件 %s 超过 %dMB", filepath.Base(path), qualificationMaxFileSize>>20)
+	}
+	return &models.QualificationFile{
+		Type:     fileType,
+		FileName: filepath.Base(path),
+		Reader:   bytes.NewReader(content),
+	}, nil
+}
+
+// UploadQualification 资质文件上传接口
+// 上传营业执照、法人身份证、开户许可证等资质文件，返回的流水号用于开户请求的 QualificationTransSerialNo
+func (s *AccountService) UploadQualification(req *models.QualificationUploadRequest) (*models.QualificationUploadResponse, error) {
+	if len(req.Files) == 0 {
+		return nil, fmt.Errorf("资质文件不能为空")
+	}
+	if len(req.Files) > qualificationMaxFiles {
+		return nil, fmt.Errorf("单次最多上传 %d 个资质文件", qualificationMaxFiles)
+	}
+	if req.TransSerialNo == "" {
+		req.TransSerialNo = utils.GetTransid("TSN")
+	}
+
+	// 读取并校验文件
+	files := make([]utils.MultipartFile, 0, len(req.Files))
+	fileInfos := make([]map[string]interface{}, 0, len(req.Files))
+	for i, f := range req.Files {
+		content, err := readQualificationFile(f)
+		if err != nil {
+			return nil, err
+		}
+		// 每个文件使用独立的表单字段，同类型的多个文件通过报文中的文件描述对应
+		fieldName := fmt.Sprintf("file%d", i+1)
+		if req.FileField != nil {
+			fieldName = req.FileField(i, f)
+		}
+		files = append(files, utils.MultipartFile{
+			FieldName: fieldName,
+			FileName:  f.FileName,
+			Content:   content,
+		})
+		if req.FileInfo != nil {
+			fileInfos = append(fileInfos, req.FileInfo(fieldName, f, content))
+			continue
+		}
+		digest := sha256.Sum256(content)
+		fileInfos = append(fileInfos, map[string]interface{}{
+			"fieldName":  fieldName,
+			"fileType":   f.Type,
+			"fileName":   f.FileName,
+			"fileSize":   len(content),
+			"fileDigest": hex.EncodeToString(digest[:]),
+		})
+	}
+
+	// 构建Header参数
+	headerPost := make(map[string]string)
+	headerPost["memberId"] = s.config.MerchantID
+	headerPost["terminalId"] = s.config.TerminalID
+	headerPost["serviceTp"] = consts.MethodQualificationUpload
+	headerPost["verifyType"] = "1" // 加密方式目前只有1种，请填：1
+
+	// 构建请求数据，文件摘要随报文加密，文件内容以multipart方式上传
+	bodyData := make(map[string]interface{})
+	bodyData["version"] = "4.0.0"
+	bodyData["transSerialNo"] = req.TransSerialNo
+	bodyData["loginNo"] = req.LoginNo
+	bodyData["files"] = fileInfos
+
+	contentData := make(map[string]interface{})
+	contentData["header"] = headerPost
+	contentData["body"] = bodyData
+
+	jsonObject, err := json.Marshal(contentData)
+	if err != nil {
+		return nil, err
+	}
+
+	// 加密请求数据
+	dataContent, err := utils.EncryptByPFXFile(string(jsonObject), s.config.PrivateKey)
+	if err != nil {
+		return nil, err
+	}
+	headerPost["content"] = dataContent
+
+	// 发送请求
+	uploadURL := s.getUploadHost(consts.MethodQualificationUpload)
+	if req.UploadURL != "" {
+		uploadURL = strings.Replace(req.UploadURL, "{报文编号}", consts.MethodQualificationUpload, 1)
+	}
+	response, err := utils.PostMultipart(headerPost, files, uploadURL)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(response) == 0 {
+		return nil, fmt.Errorf("返回异常！")
+	}
+
+	// 解密返回数据
+	rPostString, err := utils.DecryptByCERFile(string(response), s.config.BFPublicKey, s.config.BFPublicKeyPem)
+	if err != nil {
+		return nil, err
+	}
+
+	var uploadResponse models.QualificationUploadResponse
+	err = json.Unmarshal([]byte(rPostString), &uploadResponse)
+	if err != nil {
+		return nil, err
+	}
+	if uploadResponse.Body.TransSerialNo == "" {
+		uploadResponse.Body.TransSerialNo = req.TransSerialNo
+	}
+	body := uploadResponse.Body
+	return &uploadResponse, models.NewAccountError(uploadResponse.Header, body.RetCode, body.ErrorCode, body.ErrorMsg)
+}
+
+// readQualificationFile 读取资质文件并校验大小和格式
+func readQualificationFile(f models.QualificationFile) ([]byte, error) {
+	switch f.Type {
+	case models.QualificationBusinessLicense, models.QualificationIDCardFront,
+		models.QualificationIDCardBack, models.QualificationBankPermit:
+	default:
+		return nil, fmt.Errorf("不支持的资质文件类型: %s", f.Type)
+	}
+	if f.Reader == nil {
+		return nil, fmt.Errorf("资质文件 %s 内容为空", f.FileName)
+	}
+
+	ext := strings.ToLower(filepath.Ext(f.FileName))
+	contentType, ok := qualificationFormats[ext]
+	if !ok {
+		return nil, fmt.Errorf("资质文件 %s 格式不支持，仅支持 jpg、jpeg、png、pdf", f.FileName)
+	}
+
+	// 多读1字节用于判断是否超出大小限制
+	content, err := io.ReadAll(io.LimitReader(f.Reader, qualificationMaxFileSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("读取资质文件 %s 失败: %v", f.FileName, err)
+	}
+	if len(content) == 0 {
+		return nil, fmt.Errorf("资质文件 %s 内容为空", f.FileName)
+	}
+	if len(content) > qualificationMaxFileSize {
+		return nil, fmt.Errorf("资质文件 %s 超过 %dMB", f.FileName, qualificationMaxFileSize>>20)
+	}
+	if detected := http.DetectContentType(content); detected != contentType {
+		return nil, fmt.Errorf("资质文件 %s 内容与扩展名不符: %s", f.FileName, detected)
+	}
+	return content, nil
+}
+
+func (s *AccountService) getUploadHost(method string) string {
+	if s.config.ReleaseEnv {
+		return strings.Replace(consts.AccountUploadHostProd, "{报文编号}", method, 1)
+	}
+	return strings.Replace(consts.AccountUploadHostTest, "{报文编号}", method, 1)
+}
+
+// request 加密并发送账户接口请求，解密返回数据后解析到 v
+func (s *AccountService) request(serviceTp string, bodyData map[string]interface{}, v interface{}) error {
+
+	// 构建Header参数
+	headerPost := make(map[string]string)
+	headerPost["memberId"] = s.config.MerchantID
+	headerPost["terminalId"] = s.config.TerminalID
+	headerPost["serviceTp"] = serviceTp
+	headerPost["verifyType"] = "1" // 加密方式目前只有1种，请填：1
+
+	// 构建请求数据
+	contentData := make(map[string]interface{})
+	contentData["header"] = headerPost
+	contentData["body"] = bodyData
+
+	// 将请求数据转换为JSON
+	jsonObject, err := json.Marshal(contentData)
+	if err != nil {
+		return err
+	}
+	if s.config.Debug {
+		fmt.Println("JSON：", string(jsonObject))
+	}
+
+	// 加密请求数据
+	dataContent, err := utils.EncryptByPFXFile(string(jsonObject), s.config.PrivateKey)
+	if err != nil {
+		return err
+	}
+	headerPost["content"] = dataContent
+
+	// 发送请求
+	response, err := utils.Post(headerPost, s.getHost(serviceTp), "json")
+	if err != nil {
+		return err
+	}
+
+	if len(response) == 0 {
+		return fmt.Errorf("返回异常！")
+	}
+
+	// 解密返回数据
+	rPostString, err := utils.DecryptByCERFile(string(response), s.config.BFPublicKey, s.config.BFPublicKeyPem)
+	if err != nil {
+		return err
+	}
+	if s.config.Debug {
+		fmt.Println("解密明文：", rPostString)
+	}
+
+	return json.Unmarshal([]byte(rPostString), v)
+}
+
+// validateOpenAccount 校验开户请求参数
+func validateOpenAccount(req *models.AccountOpenRequest) error {
+	if req.AccType != models.AccTypePersonal && req.AccType != models.AccTypeMerchant {
+		return fmt.Errorf("不支持的账户类型: %s", req.AccType)
+	}
+	if req.LoginNo == "" {
+		return fmt.Errorf("登录号不能为空")
+	}
+	if req.CustomerName == "" || req.CertificateNo == "" {
+		return fmt.Errorf("开户名称和证件号码不能为空")
+	}
+
+	if req.AccType == models.AccTypePersonal {
+		if req.CertificateType != "ID" {
+			return fmt.Errorf("个人开户仅支持身份证(ID)，不支持证件类型: %s", req.CertificateType)
+		}
+		if req.Mobile == "" {
+			return fmt.Errorf("个人开户手机号不能为空")
+		}
+		if req.CardNo == "" {
+			return fmt.Errorf("个人开户银行卡号不能为空")
+		}
+		return nil
+	}
+
+	// 个体户绑定法人对私卡时传持卡人姓名，须与法人姓名一致；绑定对公账户时无需传
+	if req.SelfEmployed == "true" && req.CardUserName != "" && req.CardUserName != req.CorporateName {
+		return fmt.Errorf("个体户对私结算卡持卡人须为法人")
+	}
+	if req.BusinessType != "BCT2.0" && req.QualificationTransSerialNo == "" {
+		return fmt.Errorf("业务类型 %s 须上传资质文件", req.BusinessType)
+	}
+	return nil
+}
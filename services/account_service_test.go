@@ -0,0 +1,25 @@
+package services
+
+import "testing"
+
+func TestValidateDetailDateRange(t *testing.T) {
+	cases := []struct {
+		start, end string
+		ok         bool
+	}{
+		{"20240101", "20240101", true},
+		{"20240101", "20240131", true},  // 31天
+		{"20240101", "20240201", false}, // 32天
+		{"20240201", "20240302", true},  // 闰年2月，31天
+		{"20240201", "20240303", false},
+		{"20240102", "20240101", false},
+		{"2024-01-01", "20240101", false},
+		{"20240101", "202401", false},
+	}
+	for _, c := range cases {
+		err := validateDetailDateRange(c.start, c.end)
+		if (err == nil) != c.ok {
+			t.Errorf("validateDetailDateRange(%s, %s) = %v, 期望通过: %v", c.start, c.end, err, c.ok)
+		}
+	}
+}
@@ -1,9 +1,12 @@
 package services
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/url"
+	"strings"
 	"time"
 
 	"github.com/nicoaz/baofu-sdk/config"
@@ -35,152 +38,250 @@ func (s *MerchantService) getHost() string {
 }
 
 // MerchantWxReport 商户报备微信
-func (s *MerchantService) MerchantWxReport(request *models.MerchantWXReportReq) (string, error) {
-	fmt.Println("==========================")
-	fmt.Println("商户报备微信")
-	fmt.Println("==========================")
-
+func (s *MerchantService) MerchantWxReport(request *models.MerchantWXReportReq) (*models.MerchantReportResult, error) {
 	request.MerId = s.config.MerchantID
 	request.TerId = s.config.TerminalID
-	request.ReportType = "WECHAT"
+	request.ReportType = models.ReportTypeWechat
 	request.ReportNo = utils.GetTransid("BBWX")
 
-	// 将业务内容转为JSON
-	bizContentJSON, err := json.Marshal(request)
-	if err != nil {
-		return "", fmt.Errorf("业务参数JSON编码失败: %v", err)
-	}
-
-	// 生成签名
-	signStr, err := utils.Sign(string(bizContentJSON), s.config.PrivateKey)
+	dataContent, err := s.request(consts.MethodMerchantReport, request, "商户报备")
 	if err != nil {
-		return "", fmt.Errorf("生成签名失败: %v", err)
+		return nil, err
 	}
+	return parseReportResult(dataContent, request.ReportType, request.ReportNo)
+}
 
-	// 构建请求参数
-	mapParams := url.Values{}
-	mapParams.Set("method", consts.MethodMerchantReport)
-	mapParams.Set("merId", s.config.MerchantID)
-	mapParams.Set("terId", s.config.TerminalID)
-	mapParams.Set("bizContent", string(bizContentJSON))
-	mapParams.Set("charset", "UTF-8")
-	mapParams.Set("signStr", signStr)
-	mapParams.Set("version", "1.0")
-	mapParams.Set("format", "json")
-	mapParams.Set("signType", "RSA")
-	mapParams.Set("signSn", "1")
-	mapParams.Set("ncrptnSn", "1")
-	mapParams.Set("timestamp", time.Now().Format("20060102150405"))
-
-	// 发送请求
-	response, err := s.httpClient.Post(s.getHost(), mapParams)
-	if err != nil {
-		return "", fmt.Errorf("发送商户报备请求失败: %v", err)
-	}
+// MerchantReportQuery 商户报备查询
+func (s *MerchantService) MerchantReportQuery(request *models.MerchantReportQueryRequest) (*models.MerchantReportResult, error) {
+	request.MerId = s.config.MerchantID
+	request.TerId = s.config.TerminalID
 
-	// 解析响应
-	var merchantResponse models.Response
-	err = json.Unmarshal([]byte(response), &merchantResponse)
+	dataContent, err := s.request(consts.MethodMerchantReportQuery, request, "商户报备查询")
 	if err != nil {
-		return "", fmt.Errorf("解析响应失败: %v", err)
+		return nil, err
 	}
+	return parseReportResult(dataContent, request.ReportType, request.ReportNo)
+}
 
-	// 检查返回码
-	if merchantResponse.ReturnCode != "SUCCESS" {
-		return "", fmt.Errorf("商户报备请求失败: %s", merchantResponse.ReturnMsg)
+// WaitMerchantReport 按 interval 轮询报备查询，直至报备成功或被驳回
+// 轮询时长由 ctx 控制，微信、支付宝审核通常需要数分钟至数小时；ctx 结束时返回最后一次查询结果及超时错误
+// 仅网络异常等临时错误继续轮询，宝付返回失败、签名验证失败等错误立即返回
+// interval 轮询间隔，不大于0时默认30秒
+func (s *MerchantService) WaitMerchantReport(ctx context.Context, reportType, reportNo string, interval time.Duration) (*models.MerchantReportResult, error) {
+	if reportType != models.ReportTypeWechat && reportType != models.ReportTypeAlipay {
+		return nil, fmt.Errorf("不支持的报备类型: %s", reportType)
 	}
-
-	// 验证响应签名
-	verify, err := utils.VerifySign(merchantResponse.DataContent, merchantResponse.SignStr, s.config.BFPublicKey)
-	if err != nil {
-		return "", fmt.Errorf("验证响应签名失败: %v", err)
+	if reportNo == "" {
+		return nil, fmt.Errorf("报备编号不能为空")
 	}
-	if !verify {
-		fmt.Println("警告：响应签名验证失败")
+	if interval <= 0 {
+		interval = 30 * time.Second
 	}
 
-	return merchantResponse.DataContent, nil
+	var (
+		result  *models.MerchantReportResult
+		lastErr error
+	)
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			if result == nil && lastErr != nil {
+				return nil, fmt.Errorf("等待报备结果超时: %v", lastErr)
+			}
+			return result, fmt.Errorf("等待报备结果超时，报备编号 %s 仍在审核中: %v", reportNo, ctx.Err())
+		case <-timer.C:
+		}
+
+		res, err := s.MerchantReportQuery(&models.MerchantReportQueryRequest{
+			ReportType: reportType,
+			ReportNo:   reportNo,
+		})
+		if err != nil {
+			var te transportError
+			if !errors.As(err, &te) {
+				return nil, err
+			}
+			lastErr = err
+		} else {
+			result = res
+			if result.Finished() {
+				return result, nil
+			}
+		}
+		timer.Reset(interval)
+	}
 }
 
-// MerchantReportQuery 商户报备查询
-func (s *MerchantService) MerchantReportQuery(request *models.MerchantReportQueryRequest) (string, error) {
-	fmt.Println("==========================")
-	fmt.Println("商户报备查询")
-	fmt.Println("==========================")
-
+// BindSubConfig 绑定授权目录
+func (s *MerchantService) BindSubConfig(request *models.MerchantBindSubConfigRequest) (*models.MerchantBindSubConfigResult, error) {
 	request.MerId = s.config.MerchantID
 	request.TerId = s.config.TerminalID
 
-	// 将业务内容转为JSON
-	bizContentJSON, err := json.Marshal(request)
+	dataContent, err := s.request(consts.MethodBindSubConfig, request, "绑定授权目录")
 	if err != nil {
-		return "", fmt.Errorf("业务参数JSON编码失败: %v", err)
+		return nil, err
 	}
 
-	// 生成签名
-	signStr, err := utils.Sign(string(bizContentJSON), s.config.PrivateKey)
-	if err != nil {
-		return "", fmt.Errorf("生成签名失败: %v", err)
+	var result models.MerchantBindSubConfigResult
+	if err := json.Unmarshal([]byte(dataContent), &result); err != nil {
+		return nil, fmt.Errorf("解析绑定授权目录结果失败: %v", err)
 	}
+	result.Raw = dataContent
+	if result.SubMchId == "" {
+		result.SubMchId = request.SubMchId
+	}
+	if result.AuthType == "" {
+		result.AuthType = request.AuthType
+		result.AuthContent = request.AuthContent
+	}
+	return &result, nil
+}
 
-	// 构建请求参数
-	mapParams := url.Values{}
-	mapParams.Set("method", consts.MethodMerchantReportQuery)
-	mapParams.Set("merId", s.config.MerchantID)
-	mapParams.Set("terId", s.config.TerminalID)
-	mapParams.Set("bizContent", string(bizContentJSON))
-	mapParams.Set("charset", "UTF-8")
-	mapParams.Set("signStr", signStr)
-	mapParams.Set("version", "1.0")
-	mapParams.Set("format", "json")
-	mapParams.Set("signType", "RSA")
-	mapParams.Set("signSn", "1")
-	mapParams.Set("ncrptnSn", "1")
-	mapParams.Set("timestamp", time.Now().Format("20060102150405"))
+// QuerySubConfig 查询子商户当前已绑定的授权目录及appid
+// subMchId 商户识别码
+func (s *MerchantService) QuerySubConfig(subMchId string) (*models.MerchantSubConfig, error) {
+	if subMchId == "" {
+		return nil, fmt.Errorf("商户识别码不能为空")
+	}
 
-	// 发送请求
-	response, err := s.httpClient.Post(s.getHost(), mapParams)
+	request := &models.MerchantQuerySubConfigRequest{
+		MerId:    s.config.MerchantID,
+		TerId:    s.config.TerminalID,
+		SubMchId: subMchId,
+	}
+	dataContent, err := s.request(consts.MethodQuerySubConfig, request, "查询授权目录")
 	if err != nil {
-		return "", fmt.Errorf("发送商户报备查询请求失败: %v", err)
+		return nil, err
 	}
 
-	// 解析响应
-	var merchantResponse models.Response
-	err = json.Unmarshal([]byte(response), &merchantResponse)
-	if err != nil {
-		return "", fmt.Errorf("解析响应失败: %v", err)
+	var subConfig models.MerchantSubConfig
+	if err := json.Unmarshal([]byte(dataContent), &subConfig); err != nil {
+		return nil, fmt.Errorf("解析授权目录查询结果失败: %v", err)
+	}
+	subConfig.Raw = dataContent
+	if subConfig.SubMchId == "" {
+		subConfig.SubMchId = subMchId
 	}
+	return &subConfig, nil
+}
 
-	// 检查返回码
-	if merchantResponse.ReturnCode != "SUCCESS" {
-		return "", fmt.Errorf("商户报备查询请求失败: %s", merchantResponse.ReturnMsg)
+// BindSubConfigs 批量绑定授权目录及appid
+// 先查询子商户当前配置，已绑定的条目跳过；逐条绑定后再次查询确认是否生效，返回每条配置的绑定结果
+// 单条绑定失败不影响其余条目，仅查询当前配置失败时返回错误
+func (s *MerchantService) BindSubConfigs(subMchId string, entries []models.SubConfigEntry) ([]models.SubConfigBindResult, error) {
+	if subMchId == "" {
+		return nil, fmt.Errorf("商户识别码不能为空")
+	}
+	for i, entry := range entries {
+		if entry.AuthType != models.AuthTypeJSAPI && entry.AuthType != models.AuthTypeApplet {
+			return nil, fmt.Errorf("第 %d 条配置授权类型 %s 不支持批量绑定", i+1, entry.AuthType)
+		}
+		if strings.TrimSpace(entry.AuthContent) == "" {
+			return nil, fmt.Errorf("第 %d 条配置授权内容不能为空", i+1)
+		}
 	}
 
-	// 验证响应签名
-	verify, err := utils.VerifySign(merchantResponse.DataContent, merchantResponse.SignStr, s.config.BFPublicKey)
+	current, err := s.QuerySubConfig(subMchId)
 	if err != nil {
-		return "", fmt.Errorf("验证响应签名失败: %v", err)
+		return nil, err
 	}
-	if !verify {
-		fmt.Println("警告：响应签名验证失败")
+
+	results := make([]models.SubConfigBindResult, len(entries))
+	seen := make(map[string]bool, len(entries))
+	bound := 0
+	for i, entry := range entries {
+		results[i].SubConfigEntry = entry
+
+		key := entry.AuthType + "|" + strings.TrimSpace(entry.AuthContent)
+		if seen[key] || current.Bound(entry.AuthType, entry.AuthContent) {
+			results[i].Status = models.SubConfigBindSkipped
+			continue
+		}
+		seen[key] = true
+
+		res, err := s.BindSubConfig(&models.MerchantBindSubConfigRequest{
+			SubMchId:    subMchId,
+			AuthType:    entry.AuthType,
+			AuthContent: strings.TrimSpace(entry.AuthContent),
+			Remark:      entry.Remark,
+		})
+		switch {
+		case err != nil:
+			results[i].Status = models.SubConfigBindFail
+			results[i].ErrMsg = err.Error()
+		case res.ResultCode != "" && res.ResultCode != "SUCCESS":
+			results[i].Status = models.SubConfigBindFail
+			results[i].ErrMsg = fmt.Sprintf("%s %s", res.ErrCode, res.ErrMsg)
+		default:
+			results[i].Status = models.SubConfigBindSuccess
+			bound++
+		}
+	}
+	if bound == 0 {
+		return results, nil
 	}
 
-	fmt.Println("商户报备查询请求成功")
+	// 绑定后再次查询，确认配置已生效
+	current, err = s.QuerySubConfig(subMchId)
+	if err != nil {
+		for i := range results {
+			if results[i].Status == models.SubConfigBindSuccess {
+				results[i].Status = models.SubConfigBindFail
+				results[i].ErrMsg = fmt.Sprintf("绑定后查询确认失败: %v", err)
+			}
+		}
+		return results, nil
+	}
+	for i := range results {
+		if results[i].Status == models.SubConfigBindSuccess && !current.Bound(results[i].AuthType, results[i].AuthContent) {
+			results[i].Status = models.SubConfigBindFail
+			results[i].ErrMsg = "绑定后查询未生效"
+		}
+	}
 
-	return merchantResponse.DataContent, nil
+	return results, nil
 }
 
-// BindSubConfig 绑定授权目录
-func (s *MerchantService) BindSubConfig(request *models.MerchantBindSubConfigRequest) (string, error) {
-	fmt.Println("==========================")
-	fmt.Println("绑定授权目录")
-	fmt.Println("==========================")
+// MerchantAlipayReport 商户报备支付宝
+func (s *MerchantService) MerchantAlipayReport(request *models.MerchantAlipayReportReq) (*models.MerchantReportResult, error) {
+	if err := request.Validate(); err != nil {
+		return nil, err
+	}
 
 	request.MerId = s.config.MerchantID
 	request.TerId = s.config.TerminalID
+	request.ReportType = models.ReportTypeAlipay
+	if request.ReportNo == "" {
+		request.ReportNo = utils.GetTransid("BBZFB")
+	}
+
+	dataContent, err := s.request(consts.MethodMerchantReport, request, "商户报备支付宝")
+	if err != nil {
+		return nil, err
+	}
+	return parseReportResult(dataContent, request.ReportType, request.ReportNo)
+}
 
+// MerchantAlipayReportQuery 商户报备支付宝查询
+// reportNo 报备编号
+func (s *MerchantService) MerchantAlipayReportQuery(reportNo string) (*models.MerchantReportResult, error) {
+	if reportNo == "" {
+		return nil, fmt.Errorf("报备编号不能为空")
+	}
+
+	return s.MerchantReportQuery(&models.MerchantReportQueryRequest{
+		ReportType: models.ReportTypeAlipay,
+		ReportNo:   reportNo,
+	})
+}
+
+// request 签名并发送商户服务请求，校验返回码和响应签名后返回业务数据
+// action 请求名称，用于错误信息
+func (s *MerchantService) request(method string, bizContent interface{}, action string) (string, error) {
 	// 将业务内容转为JSON
-	bizContentJSON, err := json.Marshal(request)
+	bizContentJSON, err := json.Marshal(bizContent)
 	if err != nil {
 		return "", fmt.Errorf("业务参数JSON编码失败: %v", err)
 	}
@@ -193,7 +294,7 @@ func (s *MerchantService) BindSubConfig(request *models.MerchantBindSubConfigReq
 
 	// 构建请求参数
 	mapParams := url.Values{}
-	mapParams.Set("method", consts.MethodBindSubConfig)
+	mapParams.Set("method", method)
 	mapParams.Set("merId", s.config.MerchantID)
 	mapParams.Set("terId", s.config.TerminalID)
 	mapParams.Set("bizContent", string(bizContentJSON))
@@ -209,19 +310,19 @@ func (s *MerchantService) BindSubConfig(request *models.MerchantBindSubConfigReq
 	// 发送请求
 	response, err := s.httpClient.Post(s.getHost(), mapParams)
 	if err != nil {
-		return "", fmt.Errorf("发送绑定授权目录请求失败: %v", err)
+		return "", transportError{fmt.Errorf("发送%s请求失败: %v", action, err)}
 	}
 
-	// 解析响应
+	// 解析响应，网关异常时可能返回非JSON内容
 	var merchantResponse models.Response
 	err = json.Unmarshal([]byte(response), &merchantResponse)
 	if err != nil {
-		return "", fmt.Errorf("解析响应失败: %v", err)
+		return "", transportError{fmt.Errorf("解析响应失败: %v", err)}
 	}
 
 	// 检查返回码
 	if merchantResponse.ReturnCode != "SUCCESS" {
-		return "", fmt.Errorf("绑定授权目录请求失败: %s", merchantResponse.ReturnMsg)
+		return "", fmt.Errorf("%s请求失败: %s", action, merchantResponse.ReturnMsg)
 	}
 
 	// 验证响应签名
@@ -230,10 +331,33 @@ func (s *MerchantService) BindSubConfig(request *models.MerchantBindSubConfigReq
 		return "", fmt.Errorf("验证响应签名失败: %v", err)
 	}
 	if !verify {
-		fmt.Println("警告：响应签名验证失败")
+		return "", fmt.Errorf("响应签名验证失败")
 	}
 
-	fmt.Println("绑定授权目录请求成功")
-
 	return merchantResponse.DataContent, nil
 }
+
+// transportError 网络异常或网关返回非预期内容等临时错误，重试可能成功
+type transportError struct {
+	error
+}
+
+func (e transportError) Unwrap() error {
+	return e.error
+}
+
+// parseReportResult 解析报备结果，宝付未返回报备类型和编号时以请求为准
+func parseReportResult(dataContent, reportType, reportNo string) (*models.MerchantReportResult, error) {
+	var result models.MerchantReportResult
+	if err := json.Unmarshal([]byte(dataContent), &result); err != nil {
+		return nil, fmt.Errorf("解析报备结果失败: %v", err)
+	}
+	result.Raw = dataContent
+	if result.ReportType == "" {
+		result.ReportType = reportType
+	}
+	if result.ReportNo == "" {
+		result.ReportNo = reportNo
+	}
+	return &result, nil
+}
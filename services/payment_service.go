@@ -1,6 +1,7 @@
 package services
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/url"
@@ -35,6 +36,9 @@ func (s *PaymentService) getHost() string {
 
 // CreateUnifiedOrder 创建统一支付订单
 func (s *PaymentService) CreateUnifiedOrder(req *models.UnifiedOrderRequest) (*models.UnifiedOrderDataContent, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
 
 	// 构建业务内容
 	bizContent := models.BizContent{
@@ -43,7 +47,7 @@ func (s *PaymentService) CreateUnifiedOrder(req *models.UnifiedOrderRequest) (*m
 		OutTradeNo:   req.OutTradeNo,
 		TxnAmt:       req.Amount,
 		TxnTime:      utils.GetTimeFormat("YmdHis"),
-		TotalAmt:     req.Amount,
+		TotalAmt:     req.Amount + req.MktTotalAmt(), // 订单总金额=交易金额+营销总金额
 		TimeExpire:   "120",
 		ProdType:     "SHARING", // SHARING:分账产品,ORDINARY:普通产品
 		OrderType:    "7",
@@ -56,6 +60,8 @@ func (s *PaymentService) CreateUnifiedOrder(req *models.UnifiedOrderRequest) (*m
 			LocationPoint: "",
 			ClientIP:      req.ClientIP,
 		},
+		MktInfo:        req.MktInfo,
+		SharingDetails: req.SharingDetails,
 	}
 
 	// 设置支付扩展信息
@@ -64,13 +70,16 @@ func (s *PaymentService) CreateUnifiedOrder(req *models.UnifiedOrderRequest) (*m
 		// 微信支付
 		payExtend.Body = req.GoodsDesc
 
-		if req.PayCode == "WECHAT_JSAPI" {
+		if req.PayCode == models.PayCodeWechatJSAPI || req.PayCode == models.PayCodeWechatApplet {
+			// 公众号、小程序支付均需传入子应用ID及用户OpenID
 			payExtend.SubAppID = req.SubAppID   // 实际项目中应设置正确的AppID
 			payExtend.SubOpenID = req.SubOpenID // 实际项目中应设置正确的OpenID
 		} else if req.PayCode == "WECHAT_MICROPAY" {
+			payExtend.AuthCode = req.AuthCode
 			payExtend.TerminalInfo = &models.TerminalInfo{
 				PayCode:  req.PayCode,
-				DeviceID: "",
+				DeviceID: req.DeviceID,
+				DeviceIP: req.DeviceIP,
 			}
 		}
 	} else if len(req.PayCode) >= 6 && req.PayCode[:6] == "ALIPAY" {
@@ -81,9 +90,11 @@ func (s *PaymentService) CreateUnifiedOrder(req *models.UnifiedOrderRequest) (*m
 		if req.PayCode == "ALIPAY_JSAPI" {
 			payExtend.BuyerID = "" // 实际项目中应设置正确的买家ID
 		} else if req.PayCode == "ALIPAY_MICROPAY" {
+			payExtend.AuthCode = req.AuthCode
 			payExtend.TerminalInfo = &models.TerminalInfo{
 				PayCode:  req.PayCode,
-				DeviceID: "",
+				DeviceID: req.DeviceID,
+				DeviceIP: req.DeviceIP,
 			}
 		}
 	}
@@ -155,10 +166,15 @@ func (s *PaymentService) CreateUnifiedOrder(req *models.UnifiedOrderRequest) (*m
 // QueryOrder 查询订单
 // tradeNo 宝付交易号
 func (s *PaymentService) QueryOrder(tradeNo string) (*models.QueryOrderData, error) {
+	return s.queryOrder("tradeNo", tradeNo)
+}
+
+// queryOrder 按宝付交易号（tradeNo）或商户订单号（outTradeNo）查询订单
+func (s *PaymentService) queryOrder(key, value string) (*models.QueryOrderData, error) {
 
 	// 构建请求内容
-	content := fmt.Sprintf("{\"merId\":\"%s\",\"terId\":\"%s\",\"tradeNo\":\"%s\"}",
-		s.config.MerchantID, s.config.TerminalID, tradeNo)
+	content := fmt.Sprintf("{\"merId\":\"%s\",\"terId\":\"%s\",\"%s\":\"%s\"}",
+		s.config.MerchantID, s.config.TerminalID, key, value)
 
 	// 生成签名
 	signStr, err := utils.Sign(content, s.config.PrivateKey)
@@ -217,6 +233,28 @@ func (s *PaymentService) QueryOrder(tradeNo string) (*models.QueryOrderData, err
 	return &data, nil
 }
 
+// QueryOrderByOutTradeNo 按商户订单号查询订单
+// outTradeNo 商户订单号
+func (s *PaymentService) QueryOrderByOutTradeNo(outTradeNo string) (*models.QueryOrderData, error) {
+
+	// 构建请求内容
+	content := fmt.Sprintf("{\"merId\":\"%s\",\"terId\":\"%s\",\"outTradeNo\":\"%s\"}",
+		s.config.MerchantID, s.config.TerminalID, outTradeNo)
+
+	dataContent, err := s.request(consts.MethodOrderQuery, content)
+	if err != nil {
+		return nil, fmt.Errorf("订单查询失败: %v", err)
+	}
+
+	var data models.QueryOrderData
+	err = json.Unmarshal([]byte(dataContent), &data)
+	if err != nil {
+		return nil, fmt.Errorf("解析响应失败: %v", err)
+	}
+
+	return &data, nil
+}
+
 // CreateShareOrder 创建分账支付订单
 func (s *PaymentService) CreateShareOrder(req *models.ShareOrderRequest) (*models.ShareOrderContent, error) {
 
@@ -348,6 +386,28 @@ func (s *PaymentService) QueryShareOrder(tradeNo string) (*models.QueryShareOrde
 	return &data, nil
 }
 
+// QueryShareOrderByOutTradeNo 按商户分账订单号查询分账订单
+// outTradeNo 商户分账订单号
+func (s *PaymentService) QueryShareOrderByOutTradeNo(outTradeNo string) (*models.QueryShareOrderData, error) {
+
+	// 构建请求内容
+	content := fmt.Sprintf("{\"merId\":\"%s\",\"terId\":\"%s\",\"outTradeNo\":\"%s\"}",
+		s.config.MerchantID, s.config.TerminalID, outTradeNo)
+
+	dataContent, err := s.request(consts.MethodShareQuery, content)
+	if err != nil {
+		return nil, fmt.Errorf("分账订单查询失败: %v", err)
+	}
+
+	var data models.QueryShareOrderData
+	err = json.Unmarshal([]byte(dataContent), &data)
+	if err != nil {
+		return nil, fmt.Errorf("解析响应失败: %v", err)
+	}
+
+	return &data, nil
+}
+
 // CloseOrder 订单关闭
 func (s *PaymentService) CloseOrder(outTradeNo string) (*models.CloseOrderData, error) {
 
@@ -414,17 +474,26 @@ func (s *PaymentService) CloseOrder(outTradeNo string) (*models.CloseOrderData,
 
 // RefundOrder 退款请求
 func (s *PaymentService) RefundOrder(req *models.RefundRequest) (*models.RefundResponse, error) {
+	// 在副本上补全字段，不修改调用方的请求
+	refund := *req
+	// 未指定退款总金额时按退款金额加营销退款总金额计算
+	if refund.TotalAmt == 0 {
+		refund.TotalAmt = refund.RefundAmt + refund.MktRefundTotalAmt()
+	}
+	if err := refund.Validate(); err != nil {
+		return nil, notSentError{err}
+	}
 
 	// 构建请求内容
-	req.MerId = s.config.MerchantID
-	req.TerId = s.config.TerminalID
-	b, _ := json.Marshal(req)
+	refund.MerId = s.config.MerchantID
+	refund.TerId = s.config.TerminalID
+	b, _ := json.Marshal(&refund)
 	content := string(b)
 
 	// 生成签名
 	signStr, err := utils.Sign(content, s.config.PrivateKey)
 	if err != nil {
-		return nil, fmt.Errorf("生成签名失败: %v", err)
+		return nil, notSentError{fmt.Errorf("生成签名失败: %v", err)}
 	}
 
 	// 构建请求参数
@@ -543,6 +612,91 @@ func (s *PaymentService) QueryRefundOrder(outTradeNo string) (*models.RefundQuer
 	return &data, nil
 }
 
+// ParsePayNotify 验证并解析支付结果异步通知
+// dataContent 通知中的 dataContent 字段
+// signStr 通知中的 signStr 字段
+func (s *PaymentService) ParsePayNotify(dataContent, signStr string) (*models.PayNotifyData, error) {
+	var data models.PayNotifyData
+	if err := s.parseNotify(dataContent, signStr, &data); err != nil {
+		return nil, err
+	}
+	return &data, nil
+}
+
+// queryOrigin 按宝付交易号或商户订单号查询原支付订单，宝付交易号优先
+func (s *PaymentService) queryOrigin(originTradeNo, originOutTradeNo string) (*models.QueryOrderData, error) {
+	switch {
+	case originTradeNo != "":
+		return s.QueryOrder(originTradeNo)
+	case originOutTradeNo != "":
+		return s.QueryOrderByOutTradeNo(originOutTradeNo)
+	default:
+		return nil, fmt.Errorf("原支付订单宝付交易号和商户订单号不能同时为空")
+	}
+}
+
+// notSentError 请求发送前发生的本地错误，可确认宝付未受理该请求
+type notSentError struct {
+	error
+}
+
+func (e notSentError) Unwrap() error {
+	return e.error
+}
+
+// request 签名并发送聚合支付请求，校验返回码和响应签名后返回业务数据
+func (s *PaymentService) request(method, content string) (string, error) {
+	// 生成签名
+	signStr, err := utils.Sign(content, s.config.PrivateKey)
+	if err != nil {
+		return "", fmt.Errorf("生成签名失败: %v", err)
+	}
+
+	// 构建请求参数
+	mapParams := url.Values{}
+	mapParams.Set("method", method)
+	mapParams.Set("merId", s.config.MerchantID)
+	mapParams.Set("terId", s.config.TerminalID)
+	mapParams.Set("bizContent", content)
+	mapParams.Set("charset", "UTF-8")
+	mapParams.Set("signStr", signStr)
+	mapParams.Set("version", "1.0")
+	mapParams.Set("format", "json")
+	mapParams.Set("signType", "RSA")
+	mapParams.Set("signSn", "1")
+	mapParams.Set("ncrptnSn", "1")
+	mapParams.Set("timestamp", time.Now().Format("20060102150405"))
+
+	// 发送请求
+	response, err := s.httpClient.Post(s.getHost(), mapParams)
+	if err != nil {
+		return "", fmt.Errorf("发送请求失败: %v", err)
+	}
+
+	// 解析响应
+	var payResponse models.PayResponse
+	err = json.Unmarshal([]byte(response), &payResponse)
+	if err != nil {
+		return "", fmt.Errorf("解析响应失败: %v", err)
+	}
+
+	// 检查返回码
+	if payResponse.ReturnCode != "SUCCESS" {
+		return "", fmt.Errorf("%s", payResponse.ReturnMsg)
+	}
+
+	// 验证响应签名
+	verify, err := utils.VerifySign(payResponse.DataContent, payResponse.SignStr, s.config.BFPublicKey)
+	if err != nil {
+		return "", fmt.Errorf("验证响应签名失败: %v", err)
+	}
+	if !verify {
+		return "", fmt.Errorf("签名验不通过")
+	}
+
+	return payResponse.DataContent, nil
+}
+
 // VerifyNotify 验证异步通知
 func (s *PaymentService) VerifyNotify(notifyData, signature string) (bool, error) {
 	// 验证签名
@@ -556,3 +710,297 @@ func (s *PaymentService) VerifyNotify(notifyData, signature string) (bool, error
 
 	return true, nil
 }
+
+// MicroPay 条码支付（付款码支付）
+// 提交付款码下单，订单处于 WAIT_PAYING（用户输入密码中）时按 interval 轮询订单状态，
+// ctx 结束时仍未支付成功则自动关闭订单；关单后若发现订单已支付成功则自动全额退款，避免产生掉单
+// 下单请求超时或返回结果不明确时，按商户订单号查询确认订单后再走轮询及撤销流程；宝付明确返回失败时立即返回
+// ctx 控制轮询总时长，建议不小于30秒
+// interval 轮询间隔，建议5秒，不大于0时默认5秒
+func (s *PaymentService) MicroPay(ctx context.Context, req *models.UnifiedOrderRequest, interval time.Duration) (*models.QueryOrderData, error) {
+	if req.PayCode != "WECHAT_MICROPAY" && req.PayCode != "ALIPAY_MICROPAY" {
+		return nil, fmt.Errorf("不支持的条码支付方式: %s", req.PayCode)
+	}
+	if req.AuthCode == "" {
+		return nil, fmt.Errorf("付款码不能为空")
+	}
+	if req.DeviceID == "" {
+		return nil, fmt.Errorf("终端设备号不能为空")
+	}
+	if interval <= 0 {
+		interval = 5 * time.Second
+	}
+
+	// 提交付款码下单
+	var (
+		tradeNo string
+		state   models.TxnState
+	)
+	order, submitErr := s.CreateUnifiedOrder(req)
+	if submitErr == nil {
+		if order.ResultCode != "SUCCESS" {
+			pending := order.TxnState == models.WAIT_PAYING || order.TxnState == models.ABNORMAL
+			// 订单已失败或关闭，或业务失败且未生成宝付订单，可确认未扣款
+			if order.TxnState == models.PAY_ERROR || order.TxnState == models.CLOSED || (order.TradeNo == "" && !pending) {
+				return nil, fmt.Errorf("条码支付失败: %s %s", order.ErrCode, order.ErrMsg)
+			}
+		}
+		tradeNo = order.TradeNo
+		state = order.TxnState
+	}
+
+	// 下单结果不明确（请求异常、未返回宝付交易号），按商户订单号查询确认订单是否已受理
+	for tradeNo == "" {
+		data, err := s.queryOrder("outTradeNo", req.OutTradeNo)
+		if err == nil && data.TradeNo != "" {
+			tradeNo = data.TradeNo
+			state = data.TxnState
+			break
+		}
+		if err := waitInterval(ctx, interval); err != nil {
+			if submitErr != nil {
+				return nil, fmt.Errorf("条码支付结果未知，未查询到订单 %s，请稍后按商户订单号查询确认: %v", req.OutTradeNo, submitErr)
+			}
+			return nil, fmt.Errorf("条码支付结果未知，未查询到订单 %s，请稍后按商户订单号查询确认", req.OutTradeNo)
+		}
+	}
+
+	// 轮询订单状态
+	for state == models.WAIT_PAYING || state == models.ABNORMAL || state == "" {
+		if err := waitInterval(ctx, interval); err != nil {
+			return s.cancelMicroPay(tradeNo, req.OutTradeNo)
+		}
+
+		data, err := s.QueryOrder(tradeNo)
+		if err != nil {
+			// 查询异常时继续轮询，直至超时
+			continue
+		}
+		if data.TxnState == models.SUCCESS {
+			return data, nil
+		}
+		state = data.TxnState
+	}
+
+	// 订单已到达终态
+	data, err := s.QueryOrder(tradeNo)
+	if err != nil {
+		return nil, err
+	}
+	if data.TxnState != models.SUCCESS {
+		return data, fmt.Errorf("条码支付失败: %s %s", data.ErrCode, data.ErrMsg)
+	}
+	return data, nil
+}
+
+// waitInterval 等待 d 时长，ctx 先结束时返回 ctx.Err()
+func waitInterval(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// cancelMicroPay 条码支付超时撤销
+// 先关闭订单，关单后再次查询；如订单已支付成功则发起全额退款
+func (s *PaymentService) cancelMicroPay(tradeNo, outTradeNo string) (*models.QueryOrderData, error) {
+	_, closeErr := s.CloseOrder(tradeNo)
+
+	data, err := s.QueryOrder(tradeNo)
+	if err != nil {
+		if closeErr != nil {
+			return nil, fmt.Errorf("条码支付超时，关闭订单失败: %v", closeErr)
+		}
+		return nil, fmt.Errorf("条码支付超时，订单已关闭")
+	}
+
+	if data.TxnState == models.SUCCESS {
+		refund, err := s.RefundOrder(&models.RefundRequest{
+			OriginTradeNo:    tradeNo,
+			OriginOutTradeNo: outTradeNo,
+			OutTradeNo:       utils.GetTransid("MPR"),
+			RefundAmt:        data.SuccAmt,
+			TotalAmt:         data.SuccAmt,
+			TxnTime:          utils.GetTimeFormat("YmdHis"),
+			RefundReason:     "条码支付超时撤销",
+		})
+		if err != nil {
+			return data, fmt.Errorf("条码支付超时，撤销退款失败: %v", err)
+		}
+		if refund.ResultCode != "SUCCESS" {
+			return data, fmt.Errorf("条码支付超时，撤销退款失败: %s %s", refund.ErrCode, refund.ErrMsg)
+		}
+		return data, fmt.Errorf("条码支付超时，已撤销并全额退款")
+	}
+
+	if closeErr != nil && data.TxnState == models.WAIT_PAYING {
+		return data, fmt.Errorf("条码支付超时，关闭订单失败: %v", closeErr)
+	}
+	return data, fmt.Errorf("条码支付超时，订单已关闭")
+}
+
+// NativeQRCode 将扫码支付（WECHAT_NATIVE/ALIPAY_NATIVE）下单结果中的二维码链接编码为二维码
+// 返回的二维码可输出为PNG、SVG或终端字符画，用于收银屏展示和小票打印
+func (s *PaymentService) NativeQRCode(data *models.UnifiedOrderDataContent, level utils.QRLevel) (*utils.QRCode, error) {
+	if data.PayCode != models.PayCodeWechatNative && data.PayCode != models.PayCodeAlipayNative {
+		return nil, fmt.Errorf("支付方式 %s 不是扫码支付", data.PayCode)
+	}
+
+	params, err := data.PayParams()
+	if err != nil {
+		return nil, err
+	}
+
+	return utils.NewQRCode(params.QrCodeURL, level)
+}
+
+// FinishShare 完结分账，将原支付订单的未分账金额解冻给主商户
+func (s *PaymentService) FinishShare(req *models.ShareFinishRequest) (*models.ShareFinishData, error) {
+	if req.OriginTradeNo == "" && req.OriginOutTradeNo == "" {
+		return nil, fmt.Errorf("原支付订单宝付交易号和商户订单号不能同时为空")
+	}
+	if req.OutTradeNo == "" {
+		return nil, fmt.Errorf("完结分账订单号不能为空")
+	}
+
+	// 构建请求内容
+	req.MerId = s.config.MerchantID
+	req.TerId = s.config.TerminalID
+	if req.TxnTime == "" {
+		req.TxnTime = utils.GetTimeFormat("YmdHis")
+	}
+	b, _ := json.Marshal(req)
+
+	dataContent, err := s.request(consts.MethodShareFinish, string(b))
+	if err != nil {
+		return nil, fmt.Errorf("完结分账请求失败: %v", err)
+	}
+
+	var data models.ShareFinishData
+	err = json.Unmarshal([]byte(dataContent), &data)
+	if err != nil {
+		return nil, fmt.Errorf("解析响应失败: %v", err)
+	}
+
+	return &data, nil
+}
+
+// QueryShareFinish 查询完结分账订单
+// outTradeNo 商户完结分账订单号
+func (s *PaymentService) QueryShareFinish(outTradeNo string) (*models.ShareFinishData, error) {
+
+	// 构建请求内容
+	content := fmt.Sprintf("{\"merId\":\"%s\",\"terId\":\"%s\",\"outTradeNo\":\"%s\"}",
+		s.config.MerchantID, s.config.TerminalID, outTradeNo)
+
+	dataContent, err := s.request(consts.MethodShareFinishQuery, content)
+	if err != nil {
+		return nil, fmt.Errorf("完结分账查询失败: %v", err)
+	}
+
+	var data models.ShareFinishData
+	err = json.Unmarshal([]byte(dataContent), &data)
+	if err != nil {
+		return nil, fmt.Errorf("解析响应失败: %v", err)
+	}
+
+	return &data, nil
+}
+
+// ReturnShare 分账回退，从分账接收方回退已分账金额给主商户
+func (s *PaymentService) ReturnShare(req *models.ShareReturnRequest) (*models.ShareReturnData, error) {
+	if req.OriginShareTradeNo == "" && req.OriginShareOutTradeNo == "" {
+		return nil, fmt.Errorf("原分账订单宝付交易号和商户订单号不能同时为空")
+	}
+	if req.OutTradeNo == "" {
+		return nil, fmt.Errorf("分账回退订单号不能为空")
+	}
+	if req.ReturnMerId == "" {
+		return nil, fmt.Errorf("回退方商户号不能为空")
+	}
+	if req.ReturnAmt <= 0 {
+		return nil, fmt.Errorf("回退金额必须大于0")
+	}
+
+	// 构建请求内容
+	req.MerId = s.config.MerchantID
+	req.TerId = s.config.TerminalID
+	if req.TxnTime == "" {
+		req.TxnTime = utils.GetTimeFormat("YmdHis")
+	}
+	b, _ := json.Marshal(req)
+
+	dataContent, err := s.request(consts.MethodShareReturn, string(b))
+	if err != nil {
+		return nil, fmt.Errorf("分账回退请求失败: %v", err)
+	}
+
+	var data models.ShareReturnData
+	err = json.Unmarshal([]byte(dataContent), &data)
+	if err != nil {
+		return nil, fmt.Errorf("解析响应失败: %v", err)
+	}
+
+	return &data, nil
+}
+
+// QueryShareReturn 查询分账回退订单
+// outTradeNo 商户分账回退订单号
+func (s *PaymentService) QueryShareReturn(outTradeNo string) (*models.ShareReturnData, error) {
+
+	// 构建请求内容
+	content := fmt.Sprintf("{\"merId\":\"%s\",\"terId\":\"%s\",\"outTradeNo\":\"%s\"}",
+		s.config.MerchantID, s.config.TerminalID, outTradeNo)
+
+	dataContent, err := s.request(consts.MethodShareReturnQuery, content)
+	if err != nil {
+		return nil, fmt.Errorf("分账回退查询失败: %v", err)
+	}
+
+	var data models.ShareReturnData
+	err = json.Unmarshal([]byte(dataContent), &data)
+	if err != nil {
+		return nil, fmt.Errorf("解析响应失败: %v", err)
+	}
+
+	return &data, nil
+}
+
+// ParseShareFinishNotify 验证并解析完结分账异步通知
+func (s *PaymentService) ParseShareFinishNotify(dataContent, signStr string) (*models.ShareFinishData, error) {
+	var data models.ShareFinishData
+	if err := s.parseNotify(dataContent, signStr, &data); err != nil {
+		return nil, err
+	}
+	return &data, nil
+}
+
+// ParseShareReturnNotify 验证并解析分账回退异步通知
+func (s *PaymentService) ParseShareReturnNotify(dataContent, signStr string) (*models.ShareReturnData, error) {
+	var data models.ShareReturnData
+	if err := s.parseNotify(dataContent, signStr, &data); err != nil {
+		return nil, err
+	}
+	return &data, nil
+}
+
+// parseNotify 验证异步通知签名并解析通知数据
+func (s *PaymentService) parseNotify(dataContent, signStr string, v interface{}) error {
+	verify, err := utils.VerifySign(dataContent, signStr, s.config.BFPublicKey)
+	if err != nil {
+		return fmt.Errorf("通知签名验证失败: %v", err)
+	}
+	if !verify {
+		return fmt.Errorf("签名验不通过")
+	}
+
+	err = json.Unmarshal([]byte(dataContent), v)
+	if err != nil {
+		return fmt.Errorf("解析通知失败: %v", err)
+	}
+	return nil
+}
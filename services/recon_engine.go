@@ -0,0 +1,260 @@
+package services
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/nicoaz/baofu-sdk/models"
+)
+
+// LocalReconSource 商户本地交易记录来源，由接入方根据自身订单、退款、分账数据实现
+type LocalReconSource interface {
+	// ListReconRecords 查询清算日期内的本地交易记录
+	ListReconRecords(settleDate string) ([]models.LocalReconRecord, error)
+}
+
+// LocalReconSourceFunc 函数形式的本地交易记录来源
+type LocalReconSourceFunc func(settleDate string) ([]models.LocalReconRecord, error)
+
+// ListReconRecords 查询清算日期内的本地交易记录
+func (f LocalReconSourceFunc) ListReconRecords(settleDate string) ([]models.LocalReconRecord, error) {
+	return f(settleDate)
+}
+
+// ReconEngine 对账引擎，比对宝付聚合支付对账文件与本地交易记录
+type ReconEngine struct {
+	source LocalReconSource
+}
+
+// NewReconEngine 创建对账引擎
+func NewReconEngine(source LocalReconSource) *ReconEngine {
+	return &ReconEngine{
+		source: source,
+	}
+}
+
+// Reconcile 解析对账文件并与本地交易记录比对
+// r 为 ReconService.Download 下载的聚合支付对账文件
+func (e *ReconEngine) Reconcile(settleDate string, r io.Reader) (*models.ReconReport, error) {
+	var remote []models.JuheReconRecord
+	err := ParseJuheRecon(r, func(record *models.JuheReconRecord) error {
+		remote = append(remote, *record)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return e.ReconcileRecords(settleDate, remote)
+}
+
+// ReconcileRecords 将已解析的对账文件记录与本地交易记录比对
+// 按交易类型加商户订单号匹配，匹配不到时按交易类型加宝付交易号匹配，支付订单与其退款、分账记录互不覆盖
+func (e *ReconEngine) ReconcileRecords(settleDate string, remote []models.JuheReconRecord) (*models.ReconReport, error) {
+	local, err := e.source.ListReconRecords(settleDate)
+	if err != nil {
+		return nil, fmt.Errorf("查询本地交易记录失败: %v", err)
+	}
+
+	report := &models.ReconReport{
+		SettleDate:  settleDate,
+		RemoteCount: len(remote),
+		LocalCount:  len(local),
+		Diffs:       []models.ReconDiff{},
+	}
+
+	// 同一单号可能对应多条不同类型的本地记录，按单号索引全部候选记录
+	byOutTradeNo := make(map[string][]int, len(local))
+	byTradeNo := make(map[string][]int, len(local))
+	for i := range local {
+		report.LocalAmt += reconAmt(local[i].TxnType, local[i].Amount)
+		if local[i].OutTradeNo != "" {
+			byOutTradeNo[local[i].OutTradeNo] = append(byOutTradeNo[local[i].OutTradeNo], i)
+		}
+		if local[i].TradeNo != "" {
+			byTradeNo[local[i].TradeNo] = append(byTradeNo[local[i].TradeNo], i)
+		}
+	}
+
+	matched := make([]bool, len(local))
+	for i := range remote {
+		rec := &remote[i]
+		report.RemoteAmt += reconAmt(rec.TxnType, rec.TxnAmt)
+
+		j, found := matchReconLocal(local, matched, byOutTradeNo[rec.OutTradeNo], rec)
+		if j < 0 {
+			var ok bool
+			j, ok = matchReconLocal(local, matched, byTradeNo[rec.TradeNo], rec)
+			found = found || ok
+		}
+		if j < 0 {
+			if found {
+				report.Diffs = append(report.Diffs, remoteDiff(models.ReconDiffDuplicateRemote, rec))
+			} else {
+				report.Diffs = append(report.Diffs, remoteDiff(models.ReconDiffMissingLocal, rec))
+			}
+			continue
+		}
+		matched[j] = true
+
+		diffs := compareRecon(&local[j], rec)
+		if len(diffs) == 0 {
+			report.MatchedCount++
+			continue
+		}
+		report.Diffs = append(report.Diffs, diffs...)
+	}
+
+	// 本地处理中或失败的交易不会出现在对账文件中，仅报告本地成功的交易
+	for i := range local {
+		if matched[i] || reconStateClass(local[i].TxnType, local[i].State) != reconStateSuccess {
+			continue
+		}
+		report.Diffs = append(report.Diffs, models.ReconDiff{
+			Type:       models.ReconDiffMissingRemote,
+			TxnType:    local[i].TxnType,
+			OutTradeNo: local[i].OutTradeNo,
+			TradeNo:    local[i].TradeNo,
+			LocalAmt:   local[i].Amount,
+			LocalFee:   localFee(&local[i]),
+			LocalState: local[i].State,
+		})
+	}
+
+	return report, nil
+}
+
+// matchReconLocal 在候选本地记录中查找与对账文件交易类型一致且未匹配的记录
+// 任一方交易类型或宝付交易号为空时不按该项过滤；found 表示存在一致的记录（可能已被匹配）
+func matchReconLocal(local []models.LocalReconRecord, matched []bool, candidates []int, remote *models.JuheReconRecord) (j int, found bool) {
+	remoteType := normalizeReconTxnType(remote.TxnType)
+	for _, i := range candidates {
+		localType := normalizeReconTxnType(local[i].TxnType)
+		if remoteType != "" && localType != "" && remoteType != localType {
+			continue
+		}
+		if remote.TradeNo != "" && local[i].TradeNo != "" && remote.TradeNo != local[i].TradeNo {
+			continue
+		}
+		found = true
+		if !matched[i] {
+			return i, true
+		}
+	}
+	return -1, found
+}
+
+// reconAmt 按交易类型统一金额及手续费符号，退款为负数，其余交易为正数
+// 对账文件中退款金额为负数，本地退款记录可按正数或负数登记
+func reconAmt(txnType string, amt int64) int64 {
+	if amt < 0 {
+		amt = -amt
+	}
+	if normalizeReconTxnType(txnType) == "REFUND" {
+		return -amt
+	}
+	return amt
+}
+
+// normalizeReconTxnType 将对账文件及本地记录中的交易类型统一为 PAY、REFUND、SHARE
+func normalizeReconTxnType(txnType string) string {
+	switch t := strings.ToUpper(strings.TrimSpace(txnType)); t {
+	case "支付", "消费":
+		return "PAY"
+	case "退款":
+		return "REFUND"
+	case "分账":
+		return "SHARE"
+	default:
+		return t
+	}
+}
+
+// compareRecon 比对已匹配的本地记录与对账文件记录
+func compareRecon(local *models.LocalReconRecord, remote *models.JuheReconRecord) []models.ReconDiff {
+	var diffs []models.ReconDiff
+	newDiff := func(tp models.ReconDiffType) models.ReconDiff {
+		d := remoteDiff(tp, remote)
+		d.LocalAmt = local.Amount
+		d.LocalFee = localFee(local)
+		d.LocalState = local.State
+		if d.TxnType == "" {
+			d.TxnType = local.TxnType
+		}
+		return d
+	}
+
+	txnType := remote.TxnType
+	if txnType == "" {
+		txnType = local.TxnType
+	}
+	if reconAmt(local.TxnType, local.Amount) != reconAmt(txnType, remote.TxnAmt) {
+		diffs = append(diffs, newDiff(models.ReconDiffAmountMismatch))
+	}
+	if local.FeeAmt != nil && reconAmt(local.TxnType, *local.FeeAmt) != reconAmt(txnType, remote.FeeAmt) {
+		diffs = append(diffs, newDiff(models.ReconDiffFeeMismatch))
+	}
+	if !sameReconState(txnType, local.State, remote.TxnState) {
+		diffs = append(diffs, newDiff(models.ReconDiffStateMismatch))
+	}
+	return diffs
+}
+
+func remoteDiff(tp models.ReconDiffType, remote *models.JuheReconRecord) models.ReconDiff {
+	return models.ReconDiff{
+		Type:        tp,
+		TxnType:     remote.TxnType,
+		OutTradeNo:  remote.OutTradeNo,
+		TradeNo:     remote.TradeNo,
+		RemoteAmt:   remote.TxnAmt,
+		RemoteFee:   remote.FeeAmt,
+		RemoteState: remote.TxnState,
+		Line:        remote.Line,
+	}
+}
+
+func localFee(local *models.LocalReconRecord) int64 {
+	if local.FeeAmt == nil {
+		return 0
+	}
+	return *local.FeeAmt
+}
+
+const (
+	reconStateUnknown = iota
+	reconStateSuccess
+	reconStatePending
+	reconStateFail
+)
+
+// reconStateClass 将支付、退款、分账及对账文件中的状态归类
+// REFUND 对支付订单表示已退款，视为支付成功；对退款及其他交易表示退款已受理，视为处理中
+func reconStateClass(txnType, state string) int {
+	switch strings.ToUpper(strings.TrimSpace(state)) {
+	case "REFUND":
+		if normalizeReconTxnType(txnType) == "PAY" {
+			return reconStateSuccess
+		}
+		return reconStatePending
+	case "SUCCESS", "成功", "交易成功", "支付成功", "退款成功", "分账成功":
+		return reconStateSuccess
+	case "WAIT_PAYING", "ABNORMAL", "PROCESSING", "处理中", "待支付":
+		return reconStatePending
+	case "PAY_ERROR", "REFUND_ERROR", "FAIL", "CLOSED", "失败", "交易失败", "已关闭":
+		return reconStateFail
+	default:
+		return reconStateUnknown
+	}
+}
+
+func sameReconState(txnType, local, remote string) bool {
+	// 对账文件未提供状态列时，文件中的记录均为已清算交易
+	if strings.TrimSpace(remote) == "" {
+		remote = "SUCCESS"
+	}
+	lc, rc := reconStateClass(txnType, local), reconStateClass(txnType, remote)
+	if lc == reconStateUnknown || rc == reconStateUnknown {
+		return strings.EqualFold(strings.TrimSpace(local), strings.TrimSpace(remote))
+	}
+	return lc == rc
+}
@@ -0,0 +1,186 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/nicoaz/baofu-sdk/models"
+)
+
+func reconcileForTest(t *testing.T, local []models.LocalReconRecord, remote []models.JuheReconRecord) *models.ReconReport {
+	t.Helper()
+	engine := NewReconEngine(LocalReconSourceFunc(func(string) ([]models.LocalReconRecord, error) {
+		return local, nil
+	}))
+	report, err := engine.ReconcileRecords("20240101", remote)
+	if err != nil {
+		t.Fatalf("对账失败: %v", err)
+	}
+	return report
+}
+
+func diffTypes(report *models.ReconReport) []models.ReconDiffType {
+	types := make([]models.ReconDiffType, 0, len(report.Diffs))
+	for _, d := range report.Diffs {
+		types = append(types, d.Type)
+	}
+	return types
+}
+
+func int64Ptr(v int64) *int64 {
+	return &v
+}
+
+// TestReconcileFixture 对账文件中的支付及其退款与本地记录逐笔匹配，本地退款按正数登记
+func TestReconcileFixture(t *testing.T) {
+	remote := parseJuheBytes(t, readReconFixture(t, "juhe.txt"))
+	local := []models.LocalReconRecord{
+		{TxnType: "PAY", OutTradeNo: "P001", TradeNo: "T001", Amount: 10000, FeeAmt: int64Ptr(60), State: "REFUND"},
+		{TxnType: "PAY", OutTradeNo: "P002", TradeNo: "T002", Amount: 5100, State: "SUCCESS"},
+		{TxnType: "REFUND", OutTradeNo: "R001", TradeNo: "T003", Amount: 2000, FeeAmt: int64Ptr(12), State: "SUCCESS"},
+	}
+
+	report := reconcileForTest(t, local, remote)
+	if len(report.Diffs) != 0 {
+		t.Fatalf("期望无差异，实际: %+v", report.Diffs)
+	}
+	if report.MatchedCount != 3 {
+		t.Fatalf("匹配笔数 = %d, 期望 3", report.MatchedCount)
+	}
+	if report.LocalAmt != 13100 || report.RemoteAmt != 13100 {
+		t.Fatalf("金额合计 本地 %d 宝付 %d, 期望均为 13100", report.LocalAmt, report.RemoteAmt)
+	}
+}
+
+// TestReconcileSameOutTradeNo 支付与多笔退款使用相同商户订单号时按交易类型及宝付交易号区分
+func TestReconcileSameOutTradeNo(t *testing.T) {
+	local := []models.LocalReconRecord{
+		{TxnType: "REFUND", OutTradeNo: "P001", TradeNo: "T004", Amount: -1000, State: "SUCCESS"},
+		{TxnType: "PAY", OutTradeNo: "P001", TradeNo: "T001", Amount: 10000, State: "SUCCESS"},
+		{TxnType: "REFUND", OutTradeNo: "P001", TradeNo: "T003", Amount: -2000, State: "SUCCESS"},
+	}
+	remote := []models.JuheReconRecord{
+		{TxnType: "PAY", OutTradeNo: "P001", TradeNo: "T001", TxnAmt: 10000, TxnState: "SUCCESS"},
+		{TxnType: "退款", OutTradeNo: "P001", TradeNo: "T003", TxnAmt: -2000, TxnState: "SUCCESS"},
+		{TxnType: "REFUND", OutTradeNo: "P001", TradeNo: "T004", TxnAmt: -1000, TxnState: "SUCCESS"},
+	}
+
+	report := reconcileForTest(t, local, remote)
+	if len(report.Diffs) != 0 || report.MatchedCount != 3 {
+		t.Fatalf("期望3笔全部匹配，实际匹配 %d 笔，差异: %+v", report.MatchedCount, report.Diffs)
+	}
+}
+
+func TestReconcileDiffs(t *testing.T) {
+	local := []models.LocalReconRecord{
+		{TxnType: "PAY", OutTradeNo: "P001", TradeNo: "T001", Amount: 10000, State: "SUCCESS"},
+		{TxnType: "PAY", OutTradeNo: "P002", Amount: 5000, State: "SUCCESS"},
+		{TxnType: "PAY", OutTradeNo: "P003", Amount: 3000, State: "WAIT_PAYING"},
+		{TxnType: "REFUND", OutTradeNo: "R001", Amount: 500, State: "REFUND"},
+	}
+	remote := []models.JuheReconRecord{
+		{TxnType: "PAY", OutTradeNo: "P001", TradeNo: "T001", TxnAmt: 10000, TxnState: "SUCCESS"},
+		{TxnType: "PAY", OutTradeNo: "P001", TradeNo: "T001", TxnAmt: 10000, TxnState: "SUCCESS"},
+		{TxnType: "PAY", OutTradeNo: "P009", TradeNo: "T009", TxnAmt: 100, TxnState: "SUCCESS"},
+		{TxnType: "REFUND", OutTradeNo: "R001", TradeNo: "T010", TxnAmt: -600, TxnState: "SUCCESS"},
+	}
+
+	report := reconcileForTest(t, local, remote)
+	want := []models.ReconDiffType{
+		models.ReconDiffDuplicateRemote,
+		models.ReconDiffMissingLocal,
+		models.ReconDiffAmountMismatch,
+		models.ReconDiffStateMismatch,
+		models.ReconDiffMissingRemote,
+	}
+	got := diffTypes(report)
+	if len(got) != len(want) {
+		t.Fatalf("差异 = %v, 期望 %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("差异 = %v, 期望 %v", got, want)
+		}
+	}
+	if d := report.Diffs[len(report.Diffs)-1]; d.OutTradeNo != "P002" {
+		t.Fatalf("宝付缺失记录 = %+v, 期望 P002", d)
+	}
+	if report.MatchedCount != 1 {
+		t.Fatalf("匹配笔数 = %d, 期望 1", report.MatchedCount)
+	}
+}
+
+func TestMatchReconLocal(t *testing.T) {
+	local := []models.LocalReconRecord{
+		{TxnType: "PAY", OutTradeNo: "P001", TradeNo: "T001"},
+		{TxnType: "REFUND", OutTradeNo: "P001", TradeNo: "T002"},
+		{TxnType: "", OutTradeNo: "P001"},
+	}
+	all := []int{0, 1, 2}
+
+	cases := []struct {
+		name       string
+		remote     models.JuheReconRecord
+		candidates []int
+		matched    []bool
+		want       int
+		found      bool
+	}{
+		{"按类型匹配支付", models.JuheReconRecord{TxnType: "PAY", TradeNo: "T001"}, all, []bool{false, false, false}, 0, true},
+		{"中文类型匹配退款", models.JuheReconRecord{TxnType: "退款"}, all, []bool{false, false, false}, 1, true},
+		{"宝付交易号不同不匹配", models.JuheReconRecord{TxnType: "REFUND", TradeNo: "T009"}, all, []bool{false, false, false}, 2, true},
+		{"对账文件无类型时取首条未匹配记录", models.JuheReconRecord{}, all, []bool{true, false, false}, 1, true},
+		{"已全部匹配时返回重复", models.JuheReconRecord{TxnType: "PAY", TradeNo: "T001"}, all, []bool{true, false, true}, -1, true},
+		{"类型不符", models.JuheReconRecord{TxnType: "SHARE", TradeNo: "T001"}, []int{0, 1}, []bool{false, false, false}, -1, false},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			j, found := matchReconLocal(local, c.matched, c.candidates, &c.remote)
+			if j != c.want || found != c.found {
+				t.Fatalf("matchReconLocal = %d, %v, 期望 %d, %v", j, found, c.want, c.found)
+			}
+		})
+	}
+}
+
+func TestReconStateClass(t *testing.T) {
+	cases := []struct {
+		txnType, state string
+		want           int
+	}{
+		{"PAY", "SUCCESS", reconStateSuccess},
+		{"PAY", "REFUND", reconStateSuccess},
+		{"支付", "refund", reconStateSuccess},
+		{"REFUND", "REFUND", reconStatePending},
+		{"", "REFUND", reconStatePending},
+		{"REFUND", "退款成功", reconStateSuccess},
+		{"PAY", "WAIT_PAYING", reconStatePending},
+		{"SHARE", "处理中", reconStatePending},
+		{"REFUND", "REFUND_ERROR", reconStateFail},
+		{"PAY", " closed ", reconStateFail},
+		{"PAY", "UNKNOWN", reconStateUnknown},
+	}
+	for _, c := range cases {
+		if got := reconStateClass(c.txnType, c.state); got != c.want {
+			t.Errorf("reconStateClass(%q, %q) = %d, 期望 %d", c.txnType, c.state, got, c.want)
+		}
+	}
+}
+
+func TestReconAmt(t *testing.T) {
+	cases := []struct {
+		txnType string
+		amt     int64
+		want    int64
+	}{
+		{"PAY", 100, 100},
+		{"REFUND", 100, -100},
+		{"退款", -100, -100},
+		{"SHARE", 100, 100},
+		{"", -100, 100},
+	}
+	for _, c := range cases {
+		if got := reconAmt(c.txnType, c.amt); got != c.want {
+			t.Errorf("reconAmt(%q, %d) = %d, 期望 %d", c.txnType, c.amt, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,511 @@
+package services
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/nicoaz/baofu-sdk/config"
+	"github.com/nicoaz/baofu-sdk/consts"
+	"github.com/nicoaz/baofu-sdk/models"
+	"github.com/nicoaz/baofu-sdk/utils"
+)
+
+// ReconService 对账文件服务
+type ReconService struct {
+	config     *config.Config
+	httpClient *utils.HTTPClient
+}
+
+// NewReconService 创建对账文件服务
+func NewReconService(config *config.Config) *ReconService {
+	return &ReconService{
+		config:     config,
+		httpClient: utils.NewHTTPClient(),
+	}
+}
+
+// getHost 获取服务主机地址
+func (s *ReconService) getHost() string {
+	if s.config.ReleaseEnv {
+		return consts.ReconServiceHostProd
+	}
+	return consts.ReconServiceHostTest
+}
+
+// Download 下载对账文件，自动解压后以流的形式写入 w
+// fileType 对账文件类型 consts.ReconFileTypeJuhe 或 consts.ReconFileTypeAccount
+// settleDate 清算日期 yyyyMMdd
+func (s *ReconService) Download(fileType, settleDate string, w io.Writer) error {
+	if fileType != consts.ReconFileTypeJuhe && fileType != consts.ReconFileTypeAccount {
+		return fmt.Errorf("不支持的对账文件类型: %s", fileType)
+	}
+	if len(settleDate) != 8 {
+		return fmt.Errorf("清算日期格式应为yyyyMMdd: %s", settleDate)
+	}
+
+	// 生成签名
+	content := fmt.Sprintf("member_id=%s&terminal_id=%s&file_type=%s&settle_date=%s",
+		s.config.MerchantID, s.config.TerminalID, fileType, settleDate)
+	signStr, err := utils.Sign(content, s.config.PrivateKey)
+	if err != nil {
+		return fmt.Errorf("生成签名失败: %v", err)
+	}
+
+	// 构建请求参数
+	mapParams := url.Values{}
+	mapParams.Set("version", "4.0.0")
+	mapParams.Set("member_id", s.config.MerchantID)
+	mapParams.Set("terminal_id", s.config.TerminalID)
+	mapParams.Set("file_type", fileType)
+	mapParams.Set("settle_date", settleDate)
+	mapParams.Set("signature", signStr)
+
+	// 发送请求
+	body, err := s.httpClient.PostStream(s.getHost(), mapParams)
+	if err != nil {
+		return fmt.Errorf("下载对账文件失败: %v", err)
+	}
+	defer body.Close()
+
+	return extractReconFile(bufio.NewReaderSize(body, 64<<10), w)
+}
+
+// DownloadToFile 下载对账文件并保存到本地路径
+func (s *ReconService) DownloadToFile(fileType, settleDate, path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("创建对账文件失败: %v", err)
+	}
+	if err := s.Download(fileType, settleDate, f); err != nil {
+		f.Close()
+		os.Remove(path)
+		return err
+	}
+	return f.Close()
+}
+
+// extractReconFile 根据文件头识别压缩格式并解压
+func extractReconFile(r *bufio.Reader, w io.Writer) error {
+	head, _ := r.Peek(4)
+
+	switch {
+	case len(head) == 0:
+		return fmt.Errorf("对账文件为空")
+	case len(head) >= 2 && head[0] == 0x1f && head[1] == 0x8b:
+		// gzip 可直接流式解压
+		gz, err := gzip.NewReader(r)
+		if err != nil {
+			return fmt.Errorf("解压对账文件失败: %v", err)
+		}
+		defer gz.Close()
+		if _, err := io.Copy(w, gz); err != nil {
+			return fmt.Errorf("解压对账文件失败: %v", err)
+		}
+		return nil
+	case bytes.HasPrefix(head, []byte("PK\x03\x04")):
+		return extractReconZip(r, w)
+	case head[0] == '{':
+		// 下载失败时返回JSON格式的错误信息
+		msg, _ := io.ReadAll(io.LimitReader(r, 64<<10))
+		var errResp struct {
+			ReturnCode string `json:"returnCode"`
+			ReturnMsg  string `json:"returnMsg"`
+			RetMsg     string `json:"retMsg"`
+		}
+		if err := json.Unmarshal(msg, &errResp); err == nil && (errResp.ReturnMsg != "" || errResp.RetMsg != "") {
+			return fmt.Errorf("下载对账文件失败: %s%s", errResp.ReturnMsg, errResp.RetMsg)
+		}
+		return fmt.Errorf("下载对账文件失败: %s", string(msg))
+	default:
+		if _, err := io.Copy(w, r); err != nil {
+			return fmt.Errorf("读取对账文件失败: %v", err)
+		}
+		return nil
+	}
+}
+
+// extractReconZip zip 需随机读取，先落盘到临时文件再逐个解压
+// 多个文件依次写入 w，文件之间保证以换行分隔，重复的表头由 parseReconFile 跳过
+func extractReconZip(r io.Reader, w io.Writer) error {
+	tmp, err := os.CreateTemp("", "baofu-recon-*.zip")
+	if err != nil {
+		return fmt.Errorf("创建临时文件失败: %v", err)
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	size, err := io.Copy(tmp, r)
+	if err != nil {
+		return fmt.Errorf("读取对账文件失败: %v", err)
+	}
+
+	zr, err := zip.NewReader(tmp, size)
+	if err != nil {
+		return fmt.Errorf("解压对账文件失败: %v", err)
+	}
+	for _, f := range zr.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return fmt.Errorf("解压对账文件 %s 失败: %v", f.Name, err)
+		}
+		tail := &lastByteWriter{w: w}
+		_, err = io.Copy(tail, rc)
+		rc.Close()
+		if err != nil {
+			return fmt.Errorf("解压对账文件 %s 失败: %v", f.Name, err)
+		}
+		if tail.n > 0 && tail.last != '\n' {
+			if _, err := w.Write([]byte("\n")); err != nil {
+				return fmt.Errorf("解压对账文件 %s 失败: %v", f.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// lastByteWriter 记录写入的最后一个字节，用于判断文件是否以换行结尾
+type lastByteWriter struct {
+	w    io.Writer
+	n    int64
+	last byte
+}
+
+func (l *lastByteWriter) Write(p []byte) (int, error) {
+	n, err := l.w.Write(p)
+	if n > 0 {
+		l.n += int64(n)
+		l.last = p[n-1]
+	}
+	return n, err
+}
+
+// juheReconColumns 聚合支付对账文件列名，支持中英文表头
+var juheReconColumns = map[string][]string{
+	"merId":         {"merId", "商户号"},
+	"terId":         {"terId", "终端号"},
+	"tradeNo":       {"tradeNo", "宝付交易号", "宝付订单号"},
+	"outTradeNo":    {"outTradeNo", "商户订单号"},
+	"originTradeNo": {"originTradeNo", "原宝付交易号", "原交易号"},
+	"txnType":       {"txnType", "交易类型"},
+	"payCode":       {"payCode", "支付方式"},
+	"txnAmt":        {"txnAmt", "交易金额"},
+	"feeAmt":        {"feeAmt", "手续费"},
+	"settleAmt":     {"settleAmt", "清算金额", "结算金额"},
+	"txnState":      {"txnState", "交易状态", "订单状态"},
+	"txnTime":       {"txnTime", "交易时间"},
+	"finishTime":    {"finishTime", "完成时间"},
+	"clearingDate":  {"clearingDate", "清算日期"},
+}
+
+// accountReconColumns 账簿对账文件列名，支持中英文表头
+var accountReconColumns = map[string][]string{
+	"contractNo":    {"contractNo", "客户账户号", "账户号"},
+	"businessNo":    {"businessNo", "业务流水号"},
+	"transSerialNo": {"transSerialNo", "商户流水号", "请求流水号"},
+	"tradeType":     {"tradeType", "交易类型"},
+	"payerNo":       {"payerNo", "付款方"},
+	"payeeNo":       {"payeeNo", "收款方"},
+	"dealAmount":    {"dealAmount", "交易金额"},
+	"feeAmount":     {"feeAmount", "手续费"},
+	"state":         {"state", "交易状态"},
+	"tradeTime":     {"tradeTime", "交易时间"},
+	"clearingDate":  {"clearingDate", "清算日期"},
+}
+
+// ParseJuheRecon 逐行解析聚合支付对账文件，每条记录回调一次，回调返回错误时停止解析
+// 金额列单位为分
+func ParseJuheRecon(r io.Reader, fn func(*models.JuheReconRecord) error) error {
+	return parseReconFile(r, juheReconColumns, []string{"tradeNo", "outTradeNo", "txnAmt"}, func(row reconRow) error {
+		record := &models.JuheReconRecord{
+			MerID:        row.get("merId"),
+			TerID:        row.get("terId"),
+			TradeNo:      row.get("tradeNo"),
+			OutTradeNo:   row.get("outTradeNo"),
+			OriginNo:     row.get("originTradeNo"),
+			TxnType:      row.get("txnType"),
+			PayCode:      row.get("payCode"),
+			TxnState:     row.get("txnState"),
+			TxnTime:      row.get("txnTime"),
+			FinishTime:   row.get("finishTime"),
+			ClearingDate: row.get("clearingDate"),
+			Line:         row.line,
+		}
+		var err error
+		if record.TxnAmt, err = row.fen("txnAmt"); err != nil {
+			return err
+		}
+		if record.FeeAmt, err = row.fen("feeAmt"); err != nil {
+			return err
+		}
+		if record.SettleAmt, err = row.fen("settleAmt"); err != nil {
+			return err
+		}
+		return fn(record)
+	})
+}
+
+// ParseAccountRecon 逐行解析账簿对账文件，每条记录回调一次，回调返回错误时停止解析
+// 金额列单位为元，解析后换算为分
+func ParseAccountRecon(r io.Reader, fn func(*models.AccountReconRecord) error) error {
+	return parseReconFile(r, accountReconColumns, []string{"businessNo", "dealAmount"}, func(row reconRow) error {
+		record := &models.AccountReconRecord{
+			ContractNo:    row.get("contractNo"),
+			BusinessNo:    row.get("businessNo"),
+			TransSerialNo: row.get("transSerialNo"),
+			TradeType:     row.get("tradeType"),
+			PayerNo:       row.get("payerNo"),
+			PayeeNo:       row.get("payeeNo"),
+			State:         row.get("state"),
+			TradeTime:     row.get("tradeTime"),
+			ClearingDate:  row.get("clearingDate"),
+			Line:          row.line,
+		}
+		var err error
+		if record.DealAmount, err = row.yuan("dealAmount"); err != nil {
+			return err
+		}
+		if record.FeeAmount, err = row.yuan("feeAmount"); err != nil {
+			return err
+		}
+		return fn(record)
+	})
+}
+
+// reconRow 对账文件中的一行数据
+type reconRow struct {
+	line   int
+	fields []string
+	index  map[string]int
+}
+
+func (r reconRow) get(key string) string {
+	i, ok := r.index[key]
+	if !ok || i >= len(r.fields) {
+		return ""
+	}
+	return r.fields[i]
+}
+
+// fen 解析以分为单位的金额
+func (r reconRow) fen(key string) (int64, error) {
+	v := r.get(key)
+	if v == "" {
+		return 0, nil
+	}
+	amt, err := strconv.ParseInt(v, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("对账文件第 %d 行 %s 金额格式错误: %s", r.line, key, v)
+	}
+	return amt, nil
+}
+
+// yuan 解析以元为单位的金额并换算为分，不经过浮点运算
+func (r reconRow) yuan(key string) (int64, error) {
+	v := r.get(key)
+	if v == "" {
+		return 0, nil
+	}
+	amt, err := parseYuanToFen(v)
+	if err != nil {
+		return 0, fmt.Errorf("对账文件第 %d 行 %s 金额格式错误: %s", r.line, key, v)
+	}
+	return amt, nil
+}
+
+// parseYuanToFen 将 "12.34" 形式的元金额精确换算为分
+func parseYuanToFen(v string) (int64, error) {
+	raw := v
+	neg := strings.HasPrefix(v, "-")
+	if neg || strings.HasPrefix(v, "+") {
+		v = v[1:]
+	}
+	intPart, fracPart := v, ""
+	if i := strings.IndexByte(v, '.'); i >= 0 {
+		intPart, fracPart = v[:i], v[i+1:]
+	}
+	if len(fracPart) > 2 || (intPart == "" && fracPart == "") || !isDigits(intPart) || !isDigits(fracPart) {
+		return 0, fmt.Errorf("金额格式错误: %s", raw)
+	}
+	if intPart == "" {
+		intPart = "0"
+	}
+	fracPart += strings.Repeat("0", 2-len(fracPart))
+
+	yuan, err := strconv.ParseInt(intPart, 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	fen, err := strconv.ParseInt(fracPart, 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	amt := yuan*100 + fen
+	if neg {
+		amt = -amt
+	}
+	return amt, nil
+}
+
+// isDigits 是否仅由数字组成，空字符串视为是
+func isDigits(v string) bool {
+	for i := 0; i < len(v); i++ {
+		if v[i] < '0' || v[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// parseReconFile 解析带表头的分隔符文本对账文件
+// 表头之前的汇总行、空行及 # 开头的注释行会被跳过；表头之后列数不符的汇总行（如“合计”）同样跳过
+// 多个文件拼接时再次出现的表头按新表头处理，不作为数据行
+func parseReconFile(r io.Reader, columns map[string][]string, required []string, fn func(reconRow) error) error {
+	br := bufio.NewReaderSize(r, 64<<10)
+
+	// 逐行查找表头并识别分隔符
+	var (
+		index map[string]int
+		sep   string
+		width int
+		line  int
+	)
+	for index == nil {
+		text, err := br.ReadString('\n')
+		if text == "" && err != nil {
+			if err == io.EOF {
+				return fmt.Errorf("对账文件缺少表头")
+			}
+			return fmt.Errorf("读取对账文件失败: %v", err)
+		}
+		line++
+		text = strings.TrimRight(text, "\r\n")
+		if line == 1 {
+			text = strings.TrimPrefix(text, "\ufeff")
+		}
+		if strings.TrimSpace(text) == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		sep = detectReconSeparator(text)
+		fields, err := splitReconLine(text, sep)
+		if err != nil {
+			return fmt.Errorf("对账文件第 %d 行格式错误: %v", line, err)
+		}
+		if header := matchReconHeader(fields, columns); hasReconColumns(header, required) {
+			index, width = header, len(fields)
+		}
+	}
+
+	// 表头之后的内容由同一个 csv.Reader 读取，引号内的分隔符和换行不拆分
+	offset := line
+	cr := newReconCSVReader(br, sep)
+	for {
+		fields, err := cr.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			if pe, ok := err.(*csv.ParseError); ok {
+				pe.StartLine += offset
+				pe.Line += offset
+			}
+			return fmt.Errorf("对账文件格式错误: %v", err)
+		}
+		line, _ = cr.FieldPos(0)
+		line += offset
+		cleanReconFields(fields)
+		if len(fields) == 1 && fields[0] == "" {
+			continue
+		}
+
+		if header := matchReconHeader(fields, columns); hasReconColumns(header, required) {
+			index, width = header, len(fields)
+			continue
+		}
+		if len(fields) != width {
+			if strings.HasPrefix(fields[0], "合计") || strings.HasPrefix(fields[0], "总") || strings.HasPrefix(fields[0], "汇总") {
+				continue
+			}
+			return fmt.Errorf("对账文件第 %d 行列数 %d 与表头列数 %d 不符", line, len(fields), width)
+		}
+		if err := fn(reconRow{line: line, fields: fields, index: index}); err != nil {
+			return err
+		}
+	}
+}
+
+func detectReconSeparator(line string) string {
+	for _, sep := range []string{"|", "\t", ","} {
+		if strings.Contains(line, sep) {
+			return sep
+		}
+	}
+	return "|"
+}
+
+// splitReconLine 按 CSV 规则拆分表头之前的单行内容
+func splitReconLine(line, sep string) ([]string, error) {
+	fields, err := newReconCSVReader(strings.NewReader(line), sep).Read()
+	if err == io.EOF {
+		return []string{""}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	cleanReconFields(fields)
+	return fields, nil
+}
+
+func newReconCSVReader(r io.Reader, sep string) *csv.Reader {
+	cr := csv.NewReader(r)
+	cr.Comma = rune(sep[0])
+	cr.Comment = '#'
+	cr.LazyQuotes = true
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+	return cr
+}
+
+// cleanReconFields 去除字段首尾空白，以及多个文件拼接时残留的 BOM 和
+// 防止表格软件转换数字格式的 ` 或 ' 前缀
+func cleanReconFields(fields []string) {
+	for i := range fields {
+		fields[i] = strings.Trim(strings.TrimSpace(fields[i]), "`'\ufeff")
+	}
+}
+
+func matchReconHeader(fields []string, columns map[string][]string) map[string]int {
+	index := make(map[string]int)
+	for i, name := range fields {
+		for key, aliases := range columns {
+			for _, alias := range aliases {
+				if strings.EqualFold(name, alias) {
+					index[key] = i
+				}
+			}
+		}
+	}
+	return index
+}
+
+func hasReconColumns(index map[string]int, required []string) bool {
+	for _, key := range required {
+		if _, ok := index[key]; !ok {
+			return false
+		}
+	}
+	return true
+}
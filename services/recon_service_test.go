@@ -0,0 +1,237 @@
+package services
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/nicoaz/baofu-sdk/models"
+)
+
+func readReconFixture(t *testing.T, name string) []byte {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", "recon", name))
+	if err != nil {
+		t.Fatalf("读取测试文件 %s 失败: %v", name, err)
+	}
+	return data
+}
+
+func gzipBytes(t *testing.T, data []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	if _, err := gz.Write(data); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// zipBytes 按顺序打包多个文件，entries 为文件名、内容交替排列
+func zipBytes(t *testing.T, entries ...[]byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for i := 0; i+1 < len(entries); i += 2 {
+		w, err := zw.Create(string(entries[i]))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write(entries[i+1]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func extractReconBytes(t *testing.T, data []byte) []byte {
+	t.Helper()
+	var out bytes.Buffer
+	if err := extractReconFile(bufio.NewReader(bytes.NewReader(data)), &out); err != nil {
+		t.Fatalf("解压对账文件失败: %v", err)
+	}
+	return out.Bytes()
+}
+
+func parseJuheBytes(t *testing.T, data []byte) []models.JuheReconRecord {
+	t.Helper()
+	var records []models.JuheReconRecord
+	err := ParseJuheRecon(bytes.NewReader(data), func(r *models.JuheReconRecord) error {
+		records = append(records, *r)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("解析聚合支付对账文件失败: %v", err)
+	}
+	return records
+}
+
+func TestExtractReconFile(t *testing.T) {
+	plain := readReconFixture(t, "juhe.txt")
+	cases := map[string][]byte{
+		"plain": plain,
+		"gzip":  gzipBytes(t, plain),
+		"zip":   zipBytes(t, []byte("juhe.txt"), plain),
+	}
+	for name, data := range cases {
+		t.Run(name, func(t *testing.T) {
+			if got := extractReconBytes(t, data); !bytes.Equal(got, plain) {
+				t.Fatalf("解压结果与原文件不一致:\n%s", got)
+			}
+		})
+	}
+}
+
+func TestExtractReconFileError(t *testing.T) {
+	var out bytes.Buffer
+	err := extractReconFile(bufio.NewReader(strings.NewReader(`{"returnCode":"FAIL","returnMsg":"对账文件不存在"}`)), &out)
+	if err == nil || !strings.Contains(err.Error(), "对账文件不存在") {
+		t.Fatalf("期望返回下载失败信息，实际: %v", err)
+	}
+	if err := extractReconFile(bufio.NewReader(strings.NewReader("")), &out); err == nil {
+		t.Fatal("空文件应返回错误")
+	}
+}
+
+func TestParseJuheRecon(t *testing.T) {
+	records := parseJuheBytes(t, readReconFixture(t, "juhe.txt"))
+	if len(records) != 3 {
+		t.Fatalf("记录数 = %d, 期望 3", len(records))
+	}
+	want := models.JuheReconRecord{
+		MerID: "100001", TerID: "200001", TradeNo: "T003", OutTradeNo: "R001", OriginNo: "T001",
+		TxnType: "REFUND", PayCode: "WECHAT_JSAPI", TxnAmt: -2000, FeeAmt: -12, SettleAmt: -1988,
+		TxnState: "SUCCESS", TxnTime: "20240101150000", Line: 6,
+	}
+	if records[2] != want {
+		t.Fatalf("第3条记录 = %+v\n期望 %+v", records[2], want)
+	}
+}
+
+// TestParseJuheReconZipEntries 多个文件打包时第二个文件的表头、汇总行不应作为数据解析，且不以换行结尾的文件不与下一个文件首行拼接
+func TestParseJuheReconZipEntries(t *testing.T) {
+	plain := readReconFixture(t, "juhe.txt")
+	first := bytes.TrimRight(plain, "\n")
+	data := zipBytes(t, []byte("juhe_1.txt"), first, []byte("juhe_2.txt"), plain)
+
+	records := parseJuheBytes(t, extractReconBytes(t, data))
+	if len(records) != 6 {
+		t.Fatalf("记录数 = %d, 期望 6", len(records))
+	}
+	for i, r := range records {
+		if r.TradeNo == "" || r.TradeNo == "宝付交易号" {
+			t.Fatalf("第%d条记录解析错误: %+v", i+1, r)
+		}
+	}
+	if records[3].TradeNo != "T001" || records[3].TxnAmt != 10000 {
+		t.Fatalf("第二个文件首条记录 = %+v", records[3])
+	}
+}
+
+func TestParseAccountRecon(t *testing.T) {
+	plain := readReconFixture(t, "account.csv")
+	for name, data := range map[string][]byte{
+		"plain": plain,
+		"zip":   extractReconBytes(t, zipBytes(t, []byte("account.csv"), plain)),
+	} {
+		t.Run(name, func(t *testing.T) {
+			var records []models.AccountReconRecord
+			err := ParseAccountRecon(bytes.NewReader(data), func(r *models.AccountReconRecord) error {
+				records = append(records, *r)
+				return nil
+			})
+			if err != nil {
+				t.Fatalf("解析账簿对账文件失败: %v", err)
+			}
+			if len(records) != 2 {
+				t.Fatalf("记录数 = %d, 期望 2", len(records))
+			}
+			want := models.AccountReconRecord{
+				ContractNo: "CN001", BusinessNo: "B001", TransSerialNo: "TSN001", TradeType: "提现,到账",
+				PayerNo: "CN001", DealAmount: 1234, FeeAmount: 50, State: "成功",
+				TradeTime: "2024-01-01 10:00:00", Line: 2,
+			}
+			if records[0] != want {
+				t.Fatalf("第1条记录 = %+v\n期望 %+v", records[0], want)
+			}
+			if records[1].DealAmount != 10000 || records[1].PayeeNo != "CN003" {
+				t.Fatalf("第2条记录 = %+v", records[1])
+			}
+		})
+	}
+}
+
+func TestSplitReconLine(t *testing.T) {
+	cases := []struct {
+		line, sep string
+		want      []string
+	}{
+		{`a|b|c`, "|", []string{"a", "b", "c"}},
+		{`a, "b,c",d`, ",", []string{"a", "b,c", "d"}},
+		{"`123\t'456\t", "\t", []string{"123", "456", ""}},
+		{`"a|b"|c`, "|", []string{"a|b", "c"}},
+	}
+	for _, c := range cases {
+		got, err := splitReconLine(c.line, c.sep)
+		if err != nil {
+			t.Fatalf("splitReconLine(%q) 错误: %v", c.line, err)
+		}
+		if strings.Join(got, "\x00") != strings.Join(c.want, "\x00") {
+			t.Fatalf("splitReconLine(%q) = %q, 期望 %q", c.line, got, c.want)
+		}
+	}
+}
+
+func TestParseYuanToFen(t *testing.T) {
+	cases := map[string]int64{
+		"0": 0, "12": 1200, "12.3": 1230, "12.34": 1234, ".5": 50, "-0.01": -1, "+100.00": 10000,
+	}
+	for in, want := range cases {
+		got, err := parseYuanToFen(in)
+		if err != nil || got != want {
+			t.Fatalf("parseYuanToFen(%q) = %d, %v, 期望 %d", in, got, err, want)
+		}
+	}
+	for _, in := range []string{"", "1.234", "abc", "1.x", "1.-5", "1.+5", "-+1", "+-1", "1 .5", "-", "."} {
+		if _, err := parseYuanToFen(in); err == nil {
+			t.Fatalf("parseYuanToFen(%q) 应返回错误", in)
+		}
+	}
+}
+
+// TestParseReconMultilineField 引号内的换行属于同一字段，后续行号按文件实际行号计算
+func TestParseReconMultilineField(t *testing.T) {
+	data := "# 账簿对账文件\n" +
+		"客户账户号,业务流水号,交易类型,交易金额\n" +
+		"CN001,B001,\"提现\n备注\",1.00\n" +
+		"CN002,B002,转账,2.50\n"
+
+	var records []models.AccountReconRecord
+	err := ParseAccountRecon(strings.NewReader(data), func(r *models.AccountReconRecord) error {
+		records = append(records, *r)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("解析账簿对账文件失败: %v", err)
+	}
+	if len(records) != 2 {
+		t.Fatalf("记录数 = %d, 期望 2", len(records))
+	}
+	if records[0].TradeType != "提现\n备注" || records[0].Line != 3 {
+		t.Fatalf("第1条记录 = %+v", records[0])
+	}
+	if records[1].DealAmount != 250 || records[1].Line != 5 {
+		t.Fatalf("第2条记录 = %+v", records[1])
+	}
+}
@@ -0,0 +1,255 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/nicoaz/baofu-sdk/models"
+	"github.com/nicoaz/baofu-sdk/utils"
+)
+
+// RefundStore 退款记录存储，按原支付订单宝付交易号保存历次退款
+// 生产环境应使用数据库等持久化实现，保证进程重启后可退余额不丢失
+type RefundStore interface {
+	// ListRefunds 查询原支付订单的全部退款记录
+	ListRefunds(originTradeNo string) ([]models.RefundRecord, error)
+	// SaveRefund 保存退款记录，退款订单号已存在时覆盖
+	SaveRefund(record models.RefundRecord) error
+}
+
+// MemoryRefundStore 内存退款记录存储
+type MemoryRefundStore struct {
+	mu      sync.RWMutex
+	records map[string][]models.RefundRecord
+}
+
+// NewMemoryRefundStore 创建内存退款记录存储
+func NewMemoryRefundStore() *MemoryRefundStore {
+	return &MemoryRefundStore{
+		records: make(map[string][]models.RefundRecord),
+	}
+}
+
+// ListRefunds 查询原支付订单的全部退款记录
+func (m *MemoryRefundStore) ListRefunds(originTradeNo string) ([]models.RefundRecord, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	records := make([]models.RefundRecord, len(m.records[originTradeNo]))
+	copy(records, m.records[originTradeNo])
+	return records, nil
+}
+
+// SaveRefund 保存退款记录
+func (m *MemoryRefundStore) SaveRefund(record models.RefundRecord) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	records := m.records[record.OriginTradeNo]
+	for i := range records {
+		if records[i].OutTradeNo == record.OutTradeNo {
+			records[i] = record
+			return nil
+		}
+	}
+	m.records[record.OriginTradeNo] = append(records, record)
+	return nil
+}
+
+// RefundManager 退款管理，跟踪原支付订单的可退余额并在本地拦截超额退款
+type RefundManager struct {
+	payment *PaymentService
+	store   RefundStore
+	mu      sync.Mutex
+}
+
+// NewRefundManager 创建退款管理
+// store 为nil时使用内存存储
+func NewRefundManager(payment *PaymentService, store RefundStore) *RefundManager {
+	if store == nil {
+		store = NewMemoryRefundStore()
+	}
+	return &RefundManager{
+		payment: payment,
+		store:   store,
+	}
+}
+
+// Refundable 查询原支付订单的可退金额
+// originTradeNo 原支付订单宝付交易号，与 originOutTradeNo 二选一
+// originOutTradeNo 原支付订单商户订单号
+func (m *RefundManager) Refundable(originTradeNo, originOutTradeNo string) (int, *models.QueryOrderData, error) {
+	order, err := m.payment.queryOrigin(originTradeNo, originOutTradeNo)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	refunded, err := m.refundedAmt(order.TradeNo)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	return order.SuccAmt - refunded, order, nil
+}
+
+// RefundAll 退还原支付订单的全部剩余可退金额
+// req 需指定 OriginTradeNo 或 OriginOutTradeNo、OutTradeNo，RefundAmt、TotalAmt、TxnTime 自动填充
+func (m *RefundManager) RefundAll(req *models.RefundRequest) (*models.RefundResponse, error) {
+	return m.refund(req, true)
+}
+
+// RefundPartial 部分退款
+// req 需指定 OriginTradeNo 或 OriginOutTradeNo、OutTradeNo、RefundAmt，TotalAmt、TxnTime 自动填充
+func (m *RefundManager) RefundPartial(req *models.RefundRequest) (*models.RefundResponse, error) {
+	return m.refund(req, false)
+}
+
+// SyncRefunds 查询处理中的退款订单并更新本地记录，退款失败或宝付无此退款订单时重新计入可退余额
+func (m *RefundManager) SyncRefunds(originTradeNo string) ([]models.RefundRecord, error) {
+	records, err := m.store.ListRefunds(originTradeNo)
+	if err != nil {
+		return nil, fmt.Errorf("查询退款记录失败: %v", err)
+	}
+
+	for i := range records {
+		if records[i].RefundState == models.RefundStateSuccess || records[i].RefundState == models.RefundStateRefundError {
+			continue
+		}
+		data, err := m.payment.QueryRefundOrder(records[i].OutTradeNo)
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case data.RefundState != "":
+			records[i].RefundState = data.RefundState
+		case data.ResultCode != "SUCCESS":
+			// 宝付无此退款订单，说明退款请求未被受理
+			records[i].RefundState = models.RefundStateRefundError
+		default:
+			continue
+		}
+		if data.TradeNo != "" {
+			records[i].TradeNo = data.TradeNo
+		}
+		if err := m.store.SaveRefund(records[i]); err != nil {
+			return nil, fmt.Errorf("保存退款记录失败: %v", err)
+		}
+	}
+
+	return records, nil
+}
+
+func (m *RefundManager) refund(req *models.RefundRequest, all bool) (*models.RefundResponse, error) {
+	if req.OutTradeNo == "" {
+		return nil, fmt.Errorf("退款订单号不能为空")
+	}
+
+	order, err := m.payment.queryOrigin(req.OriginTradeNo, req.OriginOutTradeNo)
+	if err != nil {
+		return nil, err
+	}
+	if order.TxnState != models.SUCCESS && order.TxnState != models.REFUND {
+		return nil, fmt.Errorf("原支付订单状态 %s 不可退款", order.TxnState)
+	}
+
+	record, err := m.reserve(req, order, all)
+	if err != nil {
+		return nil, err
+	}
+
+	// 发送退款请求期间不持有锁，可退余额已由退款记录占用
+	resp, err := m.payment.RefundOrder(req)
+	if err != nil {
+		var ns notSentError
+		if errors.As(err, &ns) {
+			// 请求未发出，释放占用的可退金额
+			record.RefundState = models.RefundStateRefundError
+			if saveErr := m.store.SaveRefund(record); saveErr != nil {
+				return nil, fmt.Errorf("%v，且保存退款记录失败: %v", err, saveErr)
+			}
+		}
+		// 请求结果未知时保留异常状态，待 SyncRefunds 查询确认
+		return nil, err
+	}
+
+	record.TradeNo = resp.TradeNo
+	if resp.RefundState != "" {
+		record.RefundState = resp.RefundState
+	}
+	if resp.ResultCode != "SUCCESS" && resp.RefundState == "" {
+		record.RefundState = models.RefundStateRefundError
+	}
+	if err := m.store.SaveRefund(record); err != nil {
+		return resp, fmt.Errorf("保存退款记录失败: %v", err)
+	}
+
+	return resp, nil
+}
+
+// reserve 校验退款请求并登记退款记录占用可退金额，避免并发或请求超时后重复退款
+func (m *RefundManager) reserve(req *models.RefundRequest, order *models.QueryOrderData, all bool) (models.RefundRecord, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	records, err := m.store.ListRefunds(order.TradeNo)
+	if err != nil {
+		return models.RefundRecord{}, fmt.Errorf("查询退款记录失败: %v", err)
+	}
+	refundable := order.SuccAmt
+	for i := range records {
+		if records[i].OutTradeNo == req.OutTradeNo {
+			return models.RefundRecord{}, fmt.Errorf("退款订单号 %s 已存在", req.OutTradeNo)
+		}
+		if records[i].Occupied() {
+			refundable -= records[i].RefundAmt
+		}
+	}
+
+	if all {
+		req.RefundAmt = refundable
+	}
+	if req.RefundAmt <= 0 {
+		return models.RefundRecord{}, fmt.Errorf("原支付订单无可退金额")
+	}
+	if req.RefundAmt > refundable {
+		return models.RefundRecord{}, fmt.Errorf("退款金额 %d 超过可退金额 %d", req.RefundAmt, refundable)
+	}
+
+	req.OriginTradeNo = order.TradeNo
+	req.OriginOutTradeNo = order.OutTradeNo
+	req.TotalAmt = req.RefundAmt + req.MktRefundTotalAmt()
+	req.TxnTime = utils.GetTimeFormat("YmdHis")
+	if err := req.Validate(); err != nil {
+		return models.RefundRecord{}, err
+	}
+
+	record := models.RefundRecord{
+		OriginTradeNo:    req.OriginTradeNo,
+		OriginOutTradeNo: req.OriginOutTradeNo,
+		OutTradeNo:       req.OutTradeNo,
+		RefundAmt:        req.RefundAmt,
+		TotalAmt:         req.TotalAmt,
+		RefundState:      models.RefundStateAbnormal,
+		TxnTime:          req.TxnTime,
+	}
+	if err := m.store.SaveRefund(record); err != nil {
+		return models.RefundRecord{}, fmt.Errorf("保存退款记录失败: %v", err)
+	}
+	return record, nil
+}
+
+func (m *RefundManager) refundedAmt(originTradeNo string) (int, error) {
+	records, err := m.store.ListRefunds(originTradeNo)
+	if err != nil {
+		return 0, fmt.Errorf("查询退款记录失败: %v", err)
+	}
+
+	total := 0
+	for i := range records {
+		if records[i].Occupied() {
+			total += records[i].RefundAmt
+		}
+	}
+	return total, nil
+}
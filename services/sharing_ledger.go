@@ -0,0 +1,464 @@
+package services
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/nicoaz/baofu-sdk/models"
+	"github.com/nicoaz/baofu-sdk/utils"
+)
+
+// SharingStore 分账记录存储，按原支付订单宝付交易号保存历次分账
+// 生产环境应使用数据库等持久化实现，进程重启后可通过 SharingLedger.Reconcile 恢复
+type SharingStore interface {
+	// ListSharings 查询原支付订单的全部分账记录
+	ListSharings(originTradeNo string) ([]models.SharingRecord, error)
+	// SaveSharing 保存分账记录，分账订单号已存在时覆盖
+	SaveSharing(record models.SharingRecord) error
+	// ListSharingRefunds 查询原支付订单的全部分账退款记录
+	ListSharingRefunds(originTradeNo string) ([]models.SharingRefundRecord, error)
+	// SaveSharingRefund 保存分账退款记录，退款订单号已存在时覆盖
+	SaveSharingRefund(record models.SharingRefundRecord) error
+}
+
+// MemorySharingStore 内存分账记录存储
+type MemorySharingStore struct {
+	mu      sync.RWMutex
+	records map[string][]models.SharingRecord
+	refunds map[string][]models.SharingRefundRecord
+}
+
+// NewMemorySharingStore 创建内存分账记录存储
+func NewMemorySharingStore() *MemorySharingStore {
+	return &MemorySharingStore{
+		records: make(map[string][]models.SharingRecord),
+		refunds: make(map[string][]models.SharingRefundRecord),
+	}
+}
+
+// ListSharings 查询原支付订单的全部分账记录
+func (m *MemorySharingStore) ListSharings(originTradeNo string) ([]models.SharingRecord, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	records := make([]models.SharingRecord, len(m.records[originTradeNo]))
+	copy(records, m.records[originTradeNo])
+	return records, nil
+}
+
+// SaveSharing 保存分账记录
+func (m *MemorySharingStore) SaveSharing(record models.SharingRecord) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	records := m.records[record.OriginTradeNo]
+	for i := range records {
+		if records[i].OutTradeNo == record.OutTradeNo {
+			records[i] = record
+			return nil
+		}
+	}
+	m.records[record.OriginTradeNo] = append(records, record)
+	return nil
+}
+
+// ListSharingRefunds 查询原支付订单的全部分账退款记录
+func (m *MemorySharingStore) ListSharingRefunds(originTradeNo string) ([]models.SharingRefundRecord, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	refunds := make([]models.SharingRefundRecord, len(m.refunds[originTradeNo]))
+	copy(refunds, m.refunds[originTradeNo])
+	return refunds, nil
+}
+
+// SaveSharingRefund 保存分账退款记录
+func (m *MemorySharingStore) SaveSharingRefund(record models.SharingRefundRecord) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	refunds := m.refunds[record.OriginTradeNo]
+	for i := range refunds {
+		if refunds[i].OutTradeNo == record.OutTradeNo {
+			refunds[i] = record
+			return nil
+		}
+	}
+	m.refunds[record.OriginTradeNo] = append(refunds, record)
+	return nil
+}
+
+// SharingLedger 分账台账，跟踪一笔支付订单多次分账、退款后的未分账余额
+type SharingLedger struct {
+	payment *PaymentService
+	store   SharingStore
+	refunds RefundStore
+	mu      sync.Mutex
+}
+
+// NewSharingLedger 创建分账台账
+// store 为nil时使用内存存储
+// refunds 原支付订单的退款记录，应与 RefundManager 使用同一存储，退款金额从未分账金额中扣除；为nil时视为无退款
+func NewSharingLedger(payment *PaymentService, store SharingStore, refunds RefundStore) *SharingLedger {
+	if store == nil {
+		store = NewMemorySharingStore()
+	}
+	return &SharingLedger{
+		payment: payment,
+		store:   store,
+		refunds: refunds,
+	}
+}
+
+// Remaining 查询原支付订单的未分账金额，即成功金额减去已分账金额及已退款金额
+// originTradeNo 原支付订单宝付交易号，与 originOutTradeNo 二选一
+// originOutTradeNo 原支付订单商户订单号
+func (l *SharingLedger) Remaining(originTradeNo, originOutTradeNo string) (int, *models.QueryOrderData, error) {
+	order, err := l.payment.queryOrigin(originTradeNo, originOutTradeNo)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	shared, err := l.sharedAmt(order.TradeNo)
+	if err != nil {
+		return 0, nil, err
+	}
+	refunded, err := l.refundedAmt(order.TradeNo)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	return order.SuccAmt - shared - refunded, order, nil
+}
+
+// Records 查询原支付订单的分账记录
+func (l *SharingLedger) Records(originTradeNo string) ([]models.SharingRecord, error) {
+	return l.store.ListSharings(originTradeNo)
+}
+
+// Share 发起一次分账，分账金额合计超过未分账金额时在本地拒绝
+// req 需指定 OriginTradeNo 或 OriginOutTradeNo、OutTradeNo、SharingDetails，TxnTime 为空时自动填充
+func (l *SharingLedger) Share(req *models.ShareOrderRequest) (*models.ShareOrderContent, error) {
+	if req.OutTradeNo == "" {
+		return nil, fmt.Errorf("分账订单号不能为空")
+	}
+
+	order, err := l.payment.queryOrigin(req.OriginTradeNo, req.OriginOutTradeNo)
+	if err != nil {
+		return nil, err
+	}
+	if order.TxnState != models.SUCCESS {
+		return nil, fmt.Errorf("原支付订单状态 %s 不可分账", order.TxnState)
+	}
+
+	record, err := l.reserve(req, order)
+	if err != nil {
+		return nil, err
+	}
+
+	// 发送分账请求期间不持有锁，未分账金额已由分账记录占用；请求结果未知时由 Reconcile 查询确认
+	resp, err := l.payment.CreateShareOrder(req)
+	if err != nil {
+		return nil, err
+	}
+
+	record.TradeNo = resp.TradeNo
+	record.TxnState = resp.TxnState
+	if resp.ResultCode != "SUCCESS" && resp.TxnState == "" {
+		record.TxnState = "FAIL"
+	}
+	if err := l.store.SaveSharing(record); err != nil {
+		return resp, fmt.Errorf("保存分账记录失败: %v", err)
+	}
+
+	return resp, nil
+}
+
+// reserve 校验分账明细并登记分账记录占用未分账金额
+func (l *SharingLedger) reserve(req *models.ShareOrderRequest, order *models.QueryOrderData) (models.SharingRecord, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	records, err := l.store.ListSharings(order.TradeNo)
+	if err != nil {
+		return models.SharingRecord{}, fmt.Errorf("查询分账记录失败: %v", err)
+	}
+	refunded, err := l.refundedAmt(order.TradeNo)
+	if err != nil {
+		return models.SharingRecord{}, err
+	}
+	remaining := order.SuccAmt - refunded
+	for i := range records {
+		if records[i].OutTradeNo == req.OutTradeNo {
+			return models.SharingRecord{}, fmt.Errorf("分账订单号 %s 已存在", req.OutTradeNo)
+		}
+		remaining -= records[i].OccupiedAmt()
+	}
+	if err := models.ValidateSharingDetails(req.SharingDetails, remaining); err != nil {
+		return models.SharingRecord{}, err
+	}
+
+	req.OriginTradeNo = order.TradeNo
+	req.OriginOutTradeNo = order.OutTradeNo
+	if req.TxnTime == "" {
+		req.TxnTime = utils.GetTimeFormat("YmdHis")
+	}
+
+	record := models.SharingRecord{
+		OriginTradeNo:    req.OriginTradeNo,
+		OriginOutTradeNo: req.OriginOutTradeNo,
+		OutTradeNo:       req.OutTradeNo,
+		SharingDetails:   req.SharingDetails,
+		SharingAmt:       models.SharingTotalAmt(req.SharingDetails),
+		TxnTime:          req.TxnTime,
+	}
+	if err := l.store.SaveSharing(record); err != nil {
+		return models.SharingRecord{}, fmt.Errorf("保存分账记录失败: %v", err)
+	}
+	return record, nil
+}
+
+// Reconcile 通过分账订单查询更新未到达终态的分账记录，用于进程崩溃或请求超时后恢复台账
+// 单笔记录查询或保存失败不影响其余记录，全部处理后汇总返回错误
+func (l *SharingLedger) Reconcile(originTradeNo string) ([]models.SharingRecord, error) {
+	records, err := l.store.ListSharings(originTradeNo)
+	if err != nil {
+		return nil, fmt.Errorf("查询分账记录失败: %v", err)
+	}
+
+	var errs []string
+	for i := range records {
+		if records[i].Settled() {
+			continue
+		}
+
+		var data *models.QueryShareOrderData
+		if records[i].TradeNo != "" {
+			data, err = l.payment.QueryShareOrder(records[i].TradeNo)
+		} else {
+			data, err = l.payment.QueryShareOrderByOutTradeNo(records[i].OutTradeNo)
+		}
+		if err != nil && !shareOrderNotFound(err) {
+			errs = append(errs, fmt.Sprintf("%s: %v", records[i].OutTradeNo, err))
+			continue
+		}
+		if err != nil || (data.ResultCode != "SUCCESS" && data.TxnState == "") {
+			// 宝付无此分账订单，说明请求未被受理
+			records[i].TxnState = "FAIL"
+		} else {
+			applySharingQuery(&records[i], data)
+		}
+
+		if err := l.store.SaveSharing(records[i]); err != nil {
+			errs = append(errs, fmt.Sprintf("%s: 保存分账记录失败: %v", records[i].OutTradeNo, err))
+		}
+	}
+
+	if len(errs) > 0 {
+		return records, fmt.Errorf("%d 笔分账记录同步失败: %s", len(errs), strings.Join(errs, "; "))
+	}
+	return records, nil
+}
+
+// Record 登记分账订单查询结果（如异步通知后主动查询的结果），分账订单号不存在时忽略
+func (l *SharingLedger) Record(originTradeNo string, data *models.QueryShareOrderData) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	records, err := l.store.ListSharings(originTradeNo)
+	if err != nil {
+		return fmt.Errorf("查询分账记录失败: %v", err)
+	}
+	for i := range records {
+		if records[i].OutTradeNo == data.OutTradeNo || (data.TradeNo != "" && records[i].TradeNo == data.TradeNo) {
+			applySharingQuery(&records[i], data)
+			return l.store.SaveSharing(records[i])
+		}
+	}
+	return nil
+}
+
+// applySharingQuery 将分账订单查询结果写入分账记录，请求的分账明细保持不变，成功金额单独记录
+func applySharingQuery(record *models.SharingRecord, data *models.QueryShareOrderData) {
+	if data.TradeNo != "" {
+		record.TradeNo = data.TradeNo
+	}
+	if data.TxnState != "" {
+		record.TxnState = data.TxnState
+	}
+	if amt, err := strconv.Atoi(data.SuccAmt); err == nil && data.TxnState == string(models.SUCCESS) {
+		record.SuccAmt = amt
+	}
+}
+
+// shareOrderNotFound 分账订单查询是否返回订单不存在
+func shareOrderNotFound(err error) bool {
+	return strings.Contains(err.Error(), "不存在")
+}
+
+// refundedAmt 原支付订单已退款金额中由未分账金额承担的部分，仅明确失败的退款不计入
+// 退款已通过 RecordRefund 登记分账退款信息时，从分账商户扣回的金额不占用未分账金额
+func (l *SharingLedger) refundedAmt(originTradeNo string) (int, error) {
+	if l.refunds == nil {
+		return 0, nil
+	}
+	records, err := l.refunds.ListRefunds(originTradeNo)
+	if err != nil {
+		return 0, fmt.Errorf("查询退款记录失败: %v", err)
+	}
+	sharingRefunds, err := l.store.ListSharingRefunds(originTradeNo)
+	if err != nil {
+		return 0, fmt.Errorf("查询分账退款记录失败: %v", err)
+	}
+	clawedBack := make(map[string]int, len(sharingRefunds))
+	for i := range sharingRefunds {
+		if !sharingRefunds[i].Occupied() {
+			continue
+		}
+		for _, info := range sharingRefunds[i].SharingRefundInfo {
+			clawedBack[sharingRefunds[i].OutTradeNo] += info.SharingAmt
+		}
+	}
+
+	total := 0
+	for i := range records {
+		if !records[i].Occupied() {
+			continue
+		}
+		amt := records[i].RefundAmt - clawedBack[records[i].OutTradeNo]
+		if amt > 0 {
+			total += amt
+		}
+	}
+	return total, nil
+}
+
+func (l *SharingLedger) sharedAmt(originTradeNo string) (int, error) {
+	records, err := l.store.ListSharings(originTradeNo)
+	if err != nil {
+		return 0, fmt.Errorf("查询分账记录失败: %v", err)
+	}
+
+	total := 0
+	for i := range records {
+		total += records[i].OccupiedAmt()
+	}
+	return total, nil
+}
+
+// SharedDetails 汇总原支付订单已成功分账的明细，按商户合并
+// 宝付返回的成功金额与请求金额不一致时无法确定各商户实际分账金额，返回错误
+func (l *SharingLedger) SharedDetails(originTradeNo string) ([]models.SharingDetails, error) {
+	records, err := l.store.ListSharings(originTradeNo)
+	if err != nil {
+		return nil, fmt.Errorf("查询分账记录失败: %v", err)
+	}
+
+	var details []models.SharingDetails
+	index := make(map[string]int)
+	for i := range records {
+		if records[i].TxnState != string(models.SUCCESS) {
+			continue
+		}
+		if records[i].SuccAmt > 0 && records[i].SuccAmt != records[i].SharingAmt {
+			return nil, fmt.Errorf("分账订单 %s 成功金额 %d 与请求金额 %d 不一致", records[i].OutTradeNo, records[i].SuccAmt, records[i].SharingAmt)
+		}
+		for _, d := range records[i].SharingDetails {
+			if j, ok := index[d.SharingMerId]; ok {
+				details[j].SharingAmt += d.SharingAmt
+				continue
+			}
+			index[d.SharingMerId] = len(details)
+			details = append(details, d)
+		}
+	}
+	return details, nil
+}
+
+// RefundableDetails 汇总原支付订单各分账商户尚可扣回的金额，即已成功分账金额减去历次分账退款已扣回的金额
+// excludeOutTradeNo 计算时忽略的退款订单号，用于更新已登记的分账退款记录
+func (l *SharingLedger) RefundableDetails(originTradeNo string, excludeOutTradeNo ...string) ([]models.SharingDetails, error) {
+	details, err := l.SharedDetails(originTradeNo)
+	if err != nil {
+		return nil, err
+	}
+	refunds, err := l.store.ListSharingRefunds(originTradeNo)
+	if err != nil {
+		return nil, fmt.Errorf("查询分账退款记录失败: %v", err)
+	}
+
+	exclude := make(map[string]bool, len(excludeOutTradeNo))
+	for _, no := range excludeOutTradeNo {
+		exclude[no] = true
+	}
+	refunded := make(map[string]int)
+	for i := range refunds {
+		if !refunds[i].Occupied() || exclude[refunds[i].OutTradeNo] {
+			continue
+		}
+		for _, info := range refunds[i].SharingRefundInfo {
+			refunded[info.SharingMerId] += info.SharingAmt
+		}
+	}
+
+	result := make([]models.SharingDetails, 0, len(details))
+	for _, d := range details {
+		d.SharingAmt -= refunded[d.SharingMerId]
+		if d.SharingAmt > 0 {
+			result = append(result, d)
+		}
+	}
+	return result, nil
+}
+
+// AllocateRefund 根据台账中尚可扣回的分账金额计算分账退款信息
+// 计算结果需通过 RecordRefund 登记后再发起退款，后续分摊才会扣除本次扣回的金额
+func (l *SharingLedger) AllocateRefund(originTradeNo string, refundAmt int, mode models.SharingRefundMode, priority ...string) ([]models.SharingRefundInfo, error) {
+	details, err := l.RefundableDetails(originTradeNo)
+	if err != nil {
+		return nil, err
+	}
+	if len(details) == 0 {
+		return nil, fmt.Errorf("原支付订单无可扣回的分账金额")
+	}
+	return models.AllocateSharingRefund(details, refundAmt, mode, priority...)
+}
+
+// RecordRefund 登记或更新一笔退款的分账退款信息，任一商户扣回金额超过其尚可扣回金额时拒绝
+// 建议在发起退款前以空状态登记占用，退款结果确定后再以相同退款订单号更新状态
+func (l *SharingLedger) RecordRefund(record models.SharingRefundRecord) error {
+	if record.OriginTradeNo == "" || record.OutTradeNo == "" {
+		return fmt.Errorf("原支付订单宝付交易号和退款订单号不能为空")
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if record.Occupied() {
+		details, err := l.RefundableDetails(record.OriginTradeNo, record.OutTradeNo)
+		if err != nil {
+			return err
+		}
+		remaining := make(map[string]int, len(details))
+		for _, d := range details {
+			remaining[d.SharingMerId] = d.SharingAmt
+		}
+		requested := make(map[string]int, len(record.SharingRefundInfo))
+		for _, info := range record.SharingRefundInfo {
+			if info.SharingAmt <= 0 {
+				return fmt.Errorf("分账退款金额必须大于0: %s", info.SharingMerId)
+			}
+			requested[info.SharingMerId] += info.SharingAmt
+			if requested[info.SharingMerId] > remaining[info.SharingMerId] {
+				return fmt.Errorf("商户 %s 扣回金额 %d 超过尚可扣回金额 %d", info.SharingMerId, requested[info.SharingMerId], remaining[info.SharingMerId])
+			}
+		}
+	}
+
+	if err := l.store.SaveSharingRefund(record); err != nil {
+		return fmt.Errorf("保存分账退款记录失败: %v", err)
+	}
+	return nil
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"fmt"
 	"io"
+	"mime/multipart"
 	"net/http"
 	"net/url"
 	"strings"
@@ -71,6 +72,23 @@ func (c *HTTPClient) Post(url string, params url.Values) (string, error) {
 	return string(body), nil
 }
 
+// PostStream 发送POST请求并以流的形式返回响应体，用于下载大文件，调用方负责关闭
+func (c *HTTPClient) PostStream(url string, params url.Values) (io.ReadCloser, error) {
+	// 复用已配置的客户端（连接池、代理等），仅将超时放宽为10分钟，避免大文件下载被默认超时中断
+	client := *c.client
+	client.Timeout = 10 * time.Minute
+	resp, err := client.PostForm(url, params)
+	if err != nil {
+		return nil, fmt.Errorf("POST请求失败: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("POST请求失败: HTTP %d", resp.StatusCode)
+	}
+
+	return resp.Body, nil
+}
+
 // PostJSON 发送JSON格式的POST请求
 func (c *HTTPClient) PostJSON(url string, jsonData []byte) (string, error) {
 	// 创建请求
@@ -131,3 +149,59 @@ func Post(headers map[string]string, targetURL, contentType string) (string, err
 
 	return string(body), nil
 }
+
+// MultipartFile multipart表单中的文件
+type MultipartFile struct {
+	FieldName string // 表单字段名
+	FileName  string // 文件名
+	Content   []byte // 文件内容
+}
+
+// PostMultipart 发送multipart/form-data格式的POST请求
+func PostMultipart(fields map[string]string, files []MultipartFile, targetURL string) (string, error) {
+	var buf bytes.Buffer
+	writer := multipart.NewWriter(&buf)
+	for key, value := range fields {
+		if err := writer.WriteField(key, value); err != nil {
+			return "", fmt.Errorf("写入表单字段失败: %v", err)
+		}
+	}
+	for _, f := range files {
+		part, err := writer.CreateFormFile(f.FieldName, f.FileName)
+		if err != nil {
+			return "", fmt.Errorf("创建表单文件失败: %v", err)
+		}
+		if _, err := part.Write(f.Content); err != nil {
+			return "", fmt.Errorf("写入表单文件失败: %v", err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return "", fmt.Errorf("写入表单失败: %v", err)
+	}
+
+	// 创建请求对象
+	req, err := http.NewRequest("POST", targetURL, &buf)
+	if err != nil {
+		return "", fmt.Errorf("创建请求对象失败: %v", err)
+	}
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+
+	// 发送请求，文件上传耗时较长
+	client := &http.Client{Timeout: 120 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("发送请求失败: %v", err)
+	}
+	defer resp.Body.Close()
+
+	// 读取响应
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("读取响应失败: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("上传失败，HTTP状态码: %d", resp.StatusCode)
+	}
+
+	return string(body), nil
+}
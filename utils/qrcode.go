@@ -0,0 +1,609 @@
+package utils
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"strings"
+)
+
+// QRLevel 二维码纠错等级
+type QRLevel int
+
+const (
+	QRLevelL QRLevel = iota // 约可纠错7%
+	QRLevelM                // 约可纠错15%
+	QRLevelQ                // 约可纠错25%
+	QRLevelH                // 约可纠错30%
+)
+
+// qrQuietZone 二维码四周静区宽度（模块数）
+const qrQuietZone = 4
+
+// qrEccCodewordsPerBlock 各纠错等级、各版本每个分组的纠错码字数，下标为版本号
+var qrEccCodewordsPerBlock = [4][41]int{
+	{-1, 7, 10, 15, 20, 26, 18, 20, 24, 30, 18, 20, 24, 26, 30, 22, 24, 28, 30, 28, 28, 28, 28, 30, 30, 26, 28, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30},
+	{-1, 10, 16, 26, 18, 24, 16, 18, 22, 22, 26, 30, 22, 22, 24, 24, 28, 28, 26, 26, 26, 26, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28},
+	{-1, 13, 22, 18, 26, 18, 24, 18, 22, 20, 24, 28, 26, 24, 20, 30, 24, 28, 28, 26, 30, 28, 30, 30, 30, 30, 28, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30},
+	{-1, 17, 28, 22, 16, 22, 28, 26, 26, 24, 28, 24, 28, 22, 24, 24, 30, 28, 28, 26, 28, 30, 24, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30},
+}
+
+// qrNumErrorCorrectionBlocks 各纠错等级、各版本的纠错分组数，下标为版本号
+var qrNumErrorCorrectionBlocks = [4][41]int{
+	{-1, 1, 1, 1, 1, 1, 2, 2, 2, 2, 4, 4, 4, 4, 4, 6, 6, 6, 6, 7, 8, 8, 9, 9, 10, 12, 12, 12, 13, 14, 15, 16, 17, 18, 19, 19, 20, 21, 22, 24, 25},
+	{-1, 1, 1, 1, 2, 2, 4, 4, 4, 5, 5, 5, 8, 9, 9, 10, 10, 11, 13, 14, 16, 17, 17, 18, 20, 21, 23, 25, 26, 28, 29, 31, 33, 35, 37, 38, 40, 43, 45, 47, 49},
+	{-1, 1, 1, 2, 2, 4, 4, 6, 6, 8, 8, 8, 10, 12, 16, 12, 17, 16, 18, 21, 20, 23, 23, 25, 27, 29, 34, 34, 35, 38, 40, 43, 45, 48, 51, 53, 56, 59, 62, 65, 68},
+	{-1, 1, 1, 2, 4, 4, 4, 5, 6, 8, 8, 11, 11, 16, 16, 18, 16, 19, 21, 25, 25, 25, 34, 30, 32, 35, 37, 40, 42, 45, 48, 51, 54, 57, 60, 63, 66, 70, 74, 77, 81},
+}
+
+// qrFormatBits 格式信息中的纠错等级编码
+var qrFormatBits = [4]int{1, 0, 3, 2}
+
+// QRCode 二维码
+type QRCode struct {
+	Version    int     // 版本 1-40
+	Size       int     // 边长（模块数，不含静区）
+	Level      QRLevel // 纠错等级
+	Mask       int     // 掩码编号 0-7
+	modules    [][]bool
+	isFunction [][]bool
+}
+
+// NewQRCode 以字节模式编码内容生成二维码，自动选择能容纳内容的最小版本
+func NewQRCode(content string, level QRLevel) (*QRCode, error) {
+	return newQRCode(content, level, -1)
+}
+
+// newQRCode 生成二维码，mask 为-1时自动选择惩罚分最低的掩码
+func newQRCode(content string, level QRLevel, mask int) (*QRCode, error) {
+	if level < QRLevelL || level > QRLevelH {
+		return nil, fmt.Errorf("无效的二维码纠错等级: %d", level)
+	}
+	if content == "" {
+		return nil, fmt.Errorf("二维码内容不能为空")
+	}
+
+	data := []byte(content)
+	version := 0
+	for v := 1; v <= 40; v++ {
+		countBits := 8
+		if v >= 10 {
+			countBits = 16
+		}
+		if len(data) >= 1<<countBits {
+			continue
+		}
+		if 4+countBits+len(data)*8 <= qrNumDataCodewords(v, level)*8 {
+			version = v
+			break
+		}
+	}
+	if version == 0 {
+		return nil, fmt.Errorf("二维码内容过长: %d 字节", len(data))
+	}
+
+	// 组装数据位流：模式指示符 + 字符计数 + 数据
+	var bb qrBitBuffer
+	bb.append(0x4, 4)
+	if version >= 10 {
+		bb.append(len(data), 16)
+	} else {
+		bb.append(len(data), 8)
+	}
+	for _, b := range data {
+		bb.append(int(b), 8)
+	}
+
+	// 终止符、补齐到字节、填充码字
+	capacity := qrNumDataCodewords(version, level) * 8
+	terminator := capacity - len(bb)
+	if terminator > 4 {
+		terminator = 4
+	}
+	bb.append(0, terminator)
+	bb.append(0, (8-len(bb)%8)%8)
+	for pad := 0xEC; len(bb) < capacity; pad ^= 0xEC ^ 0x11 {
+		bb.append(pad, 8)
+	}
+
+	codewords := make([]byte, len(bb)/8)
+	for i, bit := range bb {
+		if bit {
+			codewords[i>>3] |= 1 << uint(7-(i&7))
+		}
+	}
+
+	q := &QRCode{
+		Version: version,
+		Size:    version*4 + 17,
+		Level:   level,
+	}
+	q.modules = make([][]bool, q.Size)
+	q.isFunction = make([][]bool, q.Size)
+	for i := range q.modules {
+		q.modules[i] = make([]bool, q.Size)
+		q.isFunction[i] = make([]bool, q.Size)
+	}
+
+	q.drawFunctionPatterns()
+	q.drawCodewords(q.addEccAndInterleave(codewords))
+
+	// 选择惩罚分最低的掩码
+	q.Mask = mask
+	if mask < 0 {
+		minPenalty := -1
+		for m := 0; m < 8; m++ {
+			q.applyMask(m)
+			q.drawFormatBits(m)
+			penalty := q.penaltyScore()
+			if minPenalty < 0 || penalty < minPenalty {
+				q.Mask = m
+				minPenalty = penalty
+			}
+			q.applyMask(m) // 异或两次即撤销
+		}
+	}
+	q.applyMask(q.Mask)
+	q.drawFormatBits(q.Mask)
+	q.isFunction = nil
+
+	return q, nil
+}
+
+// Dark 返回指定坐标的模块是否为深色，超出范围返回false
+func (q *QRCode) Dark(x, y int) bool {
+	return x >= 0 && x < q.Size && y >= 0 && y < q.Size && q.modules[y][x]
+}
+
+// PNG 输出PNG图片
+// scale 每个模块的像素数
+func (q *QRCode) PNG(scale int) ([]byte, error) {
+	if scale <= 0 {
+		return nil, fmt.Errorf("无效的二维码缩放比例: %d", scale)
+	}
+
+	width := (q.Size + qrQuietZone*2) * scale
+	img := image.NewPaletted(image.Rect(0, 0, width, width), color.Palette{color.White, color.Black})
+	for y := 0; y < width; y++ {
+		for x := 0; x < width; x++ {
+			if q.Dark(x/scale-qrQuietZone, y/scale-qrQuietZone) {
+				img.SetColorIndex(x, y, 1)
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, fmt.Errorf("PNG编码失败: %v", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// SVG 输出SVG矢量图
+// scale 每个模块的尺寸
+func (q *QRCode) SVG(scale int) string {
+	if scale <= 0 {
+		scale = 1
+	}
+
+	width := q.Size + qrQuietZone*2
+	var sb strings.Builder
+	sb.WriteString(`<?xml version="1.0" encoding="UTF-8"?>` + "\n")
+	fmt.Fprintf(&sb, `<svg xmlns="http://www.w3.org/2000/svg" version="1.1" width="%d" height="%d" viewBox="0 0 %d %d" stroke="none">`+"\n",
+		width*scale, width*scale, width, width)
+	sb.WriteString(`<rect width="100%" height="100%" fill="#FFFFFF"/>` + "\n")
+	sb.WriteString(`<path d="`)
+	for y := 0; y < q.Size; y++ {
+		for x := 0; x < q.Size; x++ {
+			if q.modules[y][x] {
+				fmt.Fprintf(&sb, "M%d,%dh1v1h-1z", x+qrQuietZone, y+qrQuietZone)
+			}
+		}
+	}
+	sb.WriteString(`" fill="#000000"/>` + "\n")
+	sb.WriteString("</svg>\n")
+	return sb.String()
+}
+
+// Terminal 输出可直接打印到终端的二维码（使用ANSI背景色，不受终端配色影响）
+func (q *QRCode) Terminal() string {
+	const (
+		light = "\x1b[47m  \x1b[0m"
+		dark  = "\x1b[40m  \x1b[0m"
+	)
+
+	var sb strings.Builder
+	for y := -qrQuietZone; y < q.Size+qrQuietZone; y++ {
+		for x := -qrQuietZone; x < q.Size+qrQuietZone; x++ {
+			if q.Dark(x, y) {
+				sb.WriteString(dark)
+			} else {
+				sb.WriteString(light)
+			}
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+// drawFunctionPatterns 绘制定时图形、定位图形、校正图形、格式信息和版本信息
+func (q *QRCode) drawFunctionPatterns() {
+	for i := 0; i < q.Size; i++ {
+		q.setFunctionModule(6, i, i%2 == 0)
+		q.setFunctionModule(i, 6, i%2 == 0)
+	}
+
+	q.drawFinderPattern(3, 3)
+	q.drawFinderPattern(q.Size-4, 3)
+	q.drawFinderPattern(3, q.Size-4)
+
+	positions := q.alignmentPatternPositions()
+	last := len(positions) - 1
+	for i, x := range positions {
+		for j, y := range positions {
+			if (i == 0 && j == 0) || (i == 0 && j == last) || (i == last && j == 0) {
+				continue
+			}
+			q.drawAlignmentPattern(x, y)
+		}
+	}
+
+	// 先占位，掩码确定后再写入真实格式信息
+	q.drawFormatBits(0)
+	q.drawVersion()
+}
+
+func (q *QRCode) drawFinderPattern(x, y int) {
+	for dy := -4; dy <= 4; dy++ {
+		for dx := -4; dx <= 4; dx++ {
+			xx, yy := x+dx, y+dy
+			if xx < 0 || xx >= q.Size || yy < 0 || yy >= q.Size {
+				continue
+			}
+			dist := qrMaxInt(qrAbsInt(dx), qrAbsInt(dy))
+			q.setFunctionModule(xx, yy, dist != 2 && dist != 4)
+		}
+	}
+}
+
+func (q *QRCode) drawAlignmentPattern(x, y int) {
+	for dy := -2; dy <= 2; dy++ {
+		for dx := -2; dx <= 2; dx++ {
+			q.setFunctionModule(x+dx, y+dy, qrMaxInt(qrAbsInt(dx), qrAbsInt(dy)) != 1)
+		}
+	}
+}
+
+func (q *QRCode) alignmentPatternPositions() []int {
+	if q.Version == 1 {
+		return nil
+	}
+	numAlign := q.Version/7 + 2
+	step := (q.Version*8 + numAlign*3 + 5) / (numAlign*4 - 4) * 2
+	result := make([]int, numAlign)
+	result[0] = 6
+	for i, pos := numAlign-1, q.Size-7; i >= 1; i, pos = i-1, pos-step {
+		result[i] = pos
+	}
+	return result
+}
+
+func (q *QRCode) drawFormatBits(mask int) {
+	data := qrFormatBits[q.Level]<<3 | mask
+	rem := data
+	for i := 0; i < 10; i++ {
+		rem = (rem << 1) ^ ((rem >> 9) * 0x537)
+	}
+	bits := (data<<10 | rem) ^ 0x5412
+
+	// 左上角
+	for i := 0; i <= 5; i++ {
+		q.setFunctionModule(8, i, qrGetBit(bits, i))
+	}
+	q.setFunctionModule(8, 7, qrGetBit(bits, 6))
+	q.setFunctionModule(8, 8, qrGetBit(bits, 7))
+	q.setFunctionModule(7, 8, qrGetBit(bits, 8))
+	for i := 9; i < 15; i++ {
+		q.setFunctionModule(14-i, 8, qrGetBit(bits, i))
+	}
+
+	// 右上角和左下角
+	for i := 0; i < 8; i++ {
+		q.setFunctionModule(q.Size-1-i, 8, qrGetBit(bits, i))
+	}
+	for i := 8; i < 15; i++ {
+		q.setFunctionModule(8, q.Size-15+i, qrGetBit(bits, i))
+	}
+	q.setFunctionModule(8, q.Size-8, true) // 固定深色模块
+}
+
+func (q *QRCode) drawVersion() {
+	if q.Version < 7 {
+		return
+	}
+	rem := q.Version
+	for i := 0; i < 12; i++ {
+		rem = (rem << 1) ^ ((rem >> 11) * 0x1F25)
+	}
+	bits := q.Version<<12 | rem
+
+	for i := 0; i < 18; i++ {
+		bit := qrGetBit(bits, i)
+		a := q.Size - 11 + i%3
+		b := i / 3
+		q.setFunctionModule(a, b, bit)
+		q.setFunctionModule(b, a, bit)
+	}
+}
+
+func (q *QRCode) setFunctionModule(x, y int, dark bool) {
+	q.modules[y][x] = dark
+	q.isFunction[y][x] = true
+}
+
+// addEccAndInterleave 分组计算纠错码并交织
+func (q *QRCode) addEccAndInterleave(data []byte) []byte {
+	numBlocks := qrNumErrorCorrectionBlocks[q.Level][q.Version]
+	blockEccLen := qrEccCodewordsPerBlock[q.Level][q.Version]
+	rawCodewords := qrNumRawDataModules(q.Version) / 8
+	numShortBlocks := numBlocks - rawCodewords%numBlocks
+	shortBlockLen := rawCodewords / numBlocks
+
+	divisor := qrReedSolomonDivisor(blockEccLen)
+	blocks := make([][]byte, 0, numBlocks)
+	for i, k := 0, 0; i < numBlocks; i++ {
+		datLen := shortBlockLen - blockEccLen
+		if i >= numShortBlocks {
+			datLen++
+		}
+		dat := data[k : k+datLen]
+		k += datLen
+		ecc := qrReedSolomonRemainder(dat, divisor)
+
+		block := make([]byte, 0, shortBlockLen+1)
+		block = append(block, dat...)
+		if i < numShortBlocks {
+			block = append(block, 0) // 短分组补位，交织时跳过
+		}
+		block = append(block, ecc...)
+		blocks = append(blocks, block)
+	}
+
+	result := make([]byte, 0, rawCodewords)
+	for i := range blocks[0] {
+		for j, block := range blocks {
+			if i != shortBlockLen-blockEccLen || j >= numShortBlocks {
+				result = append(result, block[i])
+			}
+		}
+	}
+	return result
+}
+
+// drawCodewords 按之字形顺序填充数据码字
+func (q *QRCode) drawCodewords(data []byte) {
+	i := 0
+	for right := q.Size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5
+		}
+		for vert := 0; vert < q.Size; vert++ {
+			for j := 0; j < 2; j++ {
+				x := right - j
+				y := vert
+				if (right+1)&2 == 0 {
+					y = q.Size - 1 - vert
+				}
+				if !q.isFunction[y][x] && i < len(data)*8 {
+					q.modules[y][x] = qrGetBit(int(data[i>>3]), 7-(i&7))
+					i++
+				}
+			}
+		}
+	}
+}
+
+func (q *QRCode) applyMask(mask int) {
+	for y := 0; y < q.Size; y++ {
+		for x := 0; x < q.Size; x++ {
+			var invert bool
+			switch mask {
+			case 0:
+				invert = (x+y)%2 == 0
+			case 1:
+				invert = y%2 == 0
+			case 2:
+				invert = x%3 == 0
+			case 3:
+				invert = (x+y)%3 == 0
+			case 4:
+				invert = (x/3+y/2)%2 == 0
+			case 5:
+				invert = x*y%2+x*y%3 == 0
+			case 6:
+				invert = (x*y%2+x*y%3)%2 == 0
+			case 7:
+				invert = ((x+y)%2+x*y%3)%2 == 0
+			}
+			if invert && !q.isFunction[y][x] {
+				q.modules[y][x] = !q.modules[y][x]
+			}
+		}
+	}
+}
+
+// penaltyScore 计算掩码惩罚分
+func (q *QRCode) penaltyScore() int {
+	result := 0
+	size := q.Size
+
+	// 规则1：行列中连续同色模块
+	for i := 0; i < size; i++ {
+		runRow, runCol := 1, 1
+		for j := 1; j < size; j++ {
+			if q.modules[i][j] == q.modules[i][j-1] {
+				runRow++
+			} else {
+				runRow = 1
+			}
+			if runRow == 5 {
+				result += 3
+			} else if runRow > 5 {
+				result++
+			}
+
+			if q.modules[j][i] == q.modules[j-1][i] {
+				runCol++
+			} else {
+				runCol = 1
+			}
+			if runCol == 5 {
+				result += 3
+			} else if runCol > 5 {
+				result++
+			}
+		}
+	}
+
+	// 规则2：2x2同色块
+	for y := 0; y < size-1; y++ {
+		for x := 0; x < size-1; x++ {
+			c := q.modules[y][x]
+			if c == q.modules[y][x+1] && c == q.modules[y+1][x] && c == q.modules[y+1][x+1] {
+				result += 3
+			}
+		}
+	}
+
+	// 规则3：类定位图形 1011101 两侧带4个浅色模块
+	patterns := [2][11]bool{
+		{true, false, true, true, true, false, true, false, false, false, false},
+		{false, false, false, false, true, false, true, true, true, false, true},
+	}
+	for i := 0; i < size; i++ {
+		for j := 0; j+11 <= size; j++ {
+			for _, p := range patterns {
+				rowMatch, colMatch := true, true
+				for k := 0; k < 11; k++ {
+					if q.modules[i][j+k] != p[k] {
+						rowMatch = false
+					}
+					if q.modules[j+k][i] != p[k] {
+						colMatch = false
+					}
+				}
+				if rowMatch {
+					result += 40
+				}
+				if colMatch {
+					result += 40
+				}
+			}
+		}
+	}
+
+	// 规则4：深色模块比例
+	dark := 0
+	for _, row := range q.modules {
+		for _, m := range row {
+			if m {
+				dark++
+			}
+		}
+	}
+	total := size * size
+	k := (qrAbsInt(dark*20-total*10)+total-1)/total - 1
+	if k > 0 {
+		result += k * 10
+	}
+	return result
+}
+
+// qrNumRawDataModules 版本可容纳的数据模块数（含纠错码、不含功能图形）
+func qrNumRawDataModules(version int) int {
+	result := (16*version+128)*version + 64
+	if version >= 2 {
+		numAlign := version/7 + 2
+		result -= (25*numAlign-10)*numAlign - 55
+		if version >= 7 {
+			result -= 36
+		}
+	}
+	return result
+}
+
+// qrNumDataCodewords 版本和纠错等级下可容纳的数据码字数
+func qrNumDataCodewords(version int, level QRLevel) int {
+	return qrNumRawDataModules(version)/8 -
+		qrEccCodewordsPerBlock[level][version]*qrNumErrorCorrectionBlocks[level][version]
+}
+
+// qrReedSolomonDivisor 生成指定次数的Reed-Solomon生成多项式
+func qrReedSolomonDivisor(degree int) []byte {
+	result := make([]byte, degree)
+	result[degree-1] = 1
+	root := byte(1)
+	for i := 0; i < degree; i++ {
+		for j := range result {
+			result[j] = qrReedSolomonMultiply(result[j], root)
+			if j+1 < len(result) {
+				result[j] ^= result[j+1]
+			}
+		}
+		root = qrReedSolomonMultiply(root, 0x02)
+	}
+	return result
+}
+
+// qrReedSolomonRemainder 计算数据的Reed-Solomon纠错码
+func qrReedSolomonRemainder(data, divisor []byte) []byte {
+	result := make([]byte, len(divisor))
+	for _, b := range data {
+		factor := b ^ result[0]
+		copy(result, result[1:])
+		result[len(result)-1] = 0
+		for i, d := range divisor {
+			result[i] ^= qrReedSolomonMultiply(d, factor)
+		}
+	}
+	return result
+}
+
+// qrReedSolomonMultiply GF(2^8)乘法，既约多项式 0x11D
+func qrReedSolomonMultiply(x, y byte) byte {
+	z := 0
+	for i := 7; i >= 0; i-- {
+		z = (z << 1) ^ ((z >> 7) * 0x11D)
+		z ^= int((y>>uint(i))&1) * int(x)
+	}
+	return byte(z)
+}
+
+// qrBitBuffer 位流缓冲
+type qrBitBuffer []bool
+
+func (b *qrBitBuffer) append(val, n int) {
+	for i := n - 1; i >= 0; i-- {
+		*b = append(*b, (val>>uint(i))&1 != 0)
+	}
+}
+
+func qrGetBit(x, i int) bool {
+	return (x>>uint(i))&1 != 0
+}
+
+func qrAbsInt(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
+func qrMaxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
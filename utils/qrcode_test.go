@@ -0,0 +1,552 @@
+package utils
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+var updateGolden = flag.Bool("update", false, "重新生成 testdata 中的二维码快照")
+
+// qrFormatTable ISO/IEC 18004 附录C 格式信息（已异或掩码 101010000010010），按纠错等级、掩码编号排列，高位在前
+var qrFormatTable = map[QRLevel][8]string{
+	QRLevelL: {"111011111000100", "111001011110011", "111110110101010", "111100010011101", "110011000101111", "110001100011000", "110110001000001", "110100101110110"},
+	QRLevelM: {"101010000010010", "101000100100101", "101111001111100", "101101101001011", "100010111111001", "100000011001110", "100111110010111", "100101010100000"},
+	QRLevelQ: {"011010101011111", "011000001101000", "011111100110001", "011101000000110", "010010010110100", "010000110000011", "010111011011010", "010101111101101"},
+	QRLevelH: {"001011010001001", "001001110111110", "001110011100111", "001100111010000", "000011101100010", "000001001010101", "000110100001100", "000100000111011"},
+}
+
+// qrVersionTable ISO/IEC 18004 附录D 版本信息，高位在前
+var qrVersionTable = map[int]string{
+	7:  "000111110010010100",
+	8:  "001000010110111100",
+	9:  "001001101010011001",
+	10: "001010010011010011",
+	40: "101000110001101001",
+}
+
+func newBlankQRCode(version int, level QRLevel) *QRCode {
+	q := &QRCode{Version: version, Size: version*4 + 17, Level: level}
+	q.modules = make([][]bool, q.Size)
+	q.isFunction = make([][]bool, q.Size)
+	for i := range q.modules {
+		q.modules[i] = make([]bool, q.Size)
+		q.isFunction[i] = make([]bool, q.Size)
+	}
+	return q
+}
+
+func parseBits(t *testing.T, s string) int {
+	t.Helper()
+	v, err := strconv.ParseInt(s, 2, 32)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return int(v)
+}
+
+func TestQRFormatBits(t *testing.T) {
+	for level, masks := range qrFormatTable {
+		for mask, want := range masks {
+			q := newBlankQRCode(1, level)
+			q.drawFormatBits(mask)
+			if got := readQRFormat(q.modules, q.Size, false); got != parseBits(t, want) {
+				t.Errorf("level %d mask %d: 左上角格式信息 %015b, 期望 %s", level, mask, got, want)
+			}
+			if got := readQRFormat(q.modules, q.Size, true); got != parseBits(t, want) {
+				t.Errorf("level %d mask %d: 右上/左下格式信息 %015b, 期望 %s", level, mask, got, want)
+			}
+		}
+	}
+}
+
+func TestQRVersionBits(t *testing.T) {
+	for version, want := range qrVersionTable {
+		q := newBlankQRCode(version, QRLevelL)
+		q.drawVersion()
+		if got := readQRVersion(q.modules, q.Size, false); got != parseBits(t, want) {
+			t.Errorf("version %d: 右上角版本信息 %018b, 期望 %s", version, got, want)
+		}
+		if got := readQRVersion(q.modules, q.Size, true); got != parseBits(t, want) {
+			t.Errorf("version %d: 左下角版本信息 %018b, 期望 %s", version, got, want)
+		}
+	}
+}
+
+func TestQRAlignmentPatternPositions(t *testing.T) {
+	// ISO/IEC 18004 附录E
+	cases := map[int][]int{
+		1:  nil,
+		2:  {6, 18},
+		7:  {6, 22, 38},
+		14: {6, 26, 46, 66},
+		32: {6, 34, 60, 86, 112, 138},
+		36: {6, 24, 50, 76, 102, 128, 154},
+		40: {6, 30, 58, 86, 114, 142, 170},
+	}
+	for version, want := range cases {
+		got := newBlankQRCode(version, QRLevelL).alignmentPatternPositions()
+		if fmt.Sprint(got) != fmt.Sprint(want) {
+			t.Errorf("version %d: 校正图形位置 %v, 期望 %v", version, got, want)
+		}
+	}
+}
+
+func TestQRDataCodewords(t *testing.T) {
+	// ISO/IEC 18004 表7 数据码字数，按 L M Q H 排列
+	cases := map[int][4]int{
+		1:  {19, 16, 13, 9},
+		2:  {34, 28, 22, 16},
+		5:  {108, 86, 62, 46},
+		7:  {156, 124, 88, 66},
+		10: {274, 216, 154, 122},
+		14: {461, 365, 261, 197},
+		40: {2956, 2334, 1666, 1276},
+	}
+	for version, want := range cases {
+		for level := QRLevelL; level <= QRLevelH; level++ {
+			if got := qrNumDataCodewords(version, level); got != want[level] {
+				t.Errorf("version %d level %d: 数据码字数 %d, 期望 %d", version, level, got, want[level])
+			}
+		}
+	}
+}
+
+func TestQRReedSolomon(t *testing.T) {
+	// 1-M "HELLO WORLD" 的数据码字及纠错码字
+	data := []byte{32, 91, 11, 120, 209, 114, 220, 77, 67, 64, 236, 17, 236, 17, 236, 17}
+	want := []byte{196, 35, 39, 119, 235, 215, 231, 226, 93, 23}
+	if got := qrReedSolomonRemainder(data, qrReedSolomonDivisor(len(want))); !bytes.Equal(got, want) {
+		t.Errorf("纠错码字 %v, 期望 %v", got, want)
+	}
+}
+
+func TestQRByteCapacity(t *testing.T) {
+	// ISO/IEC 18004 表7 字节模式容量
+	cases := []struct {
+		level   QRLevel
+		length  int
+		version int
+	}{
+		{QRLevelL, 17, 1},
+		{QRLevelH, 7, 1},
+		{QRLevelM, 213, 10},
+		{QRLevelL, 2953, 40},
+		{QRLevelH, 1273, 40},
+	}
+	for _, c := range cases {
+		q, err := NewQRCode(strings.Repeat("a", c.length), c.level)
+		if err != nil {
+			t.Fatalf("level %d 长度 %d: %v", c.level, c.length, err)
+		}
+		if q.Version != c.version {
+			t.Errorf("level %d 长度 %d: 版本 %d, 期望 %d", c.level, c.length, q.Version, c.version)
+		}
+		if c.version == 40 {
+			if _, err := NewQRCode(strings.Repeat("a", c.length+1), c.level); err == nil {
+				t.Errorf("level %d 长度 %d: 超出容量应返回错误", c.level, c.length+1)
+			}
+		} else if q, _ := NewQRCode(strings.Repeat("a", c.length+1), c.level); q.Version != c.version+1 {
+			t.Errorf("level %d 长度 %d: 版本 %d, 期望 %d", c.level, c.length+1, q.Version, c.version+1)
+		}
+	}
+}
+
+func TestQRRoundTrip(t *testing.T) {
+	for _, version := range []int{1, 2, 5, 7, 9, 10, 14, 27, 40} {
+		for level := QRLevelL; level <= QRLevelH; level++ {
+			content := qrTestContent(version, level)
+			q, err := NewQRCode(content, level)
+			if err != nil {
+				t.Fatalf("version %d level %d: %v", version, level, err)
+			}
+			if q.Version != version {
+				t.Fatalf("version %d level %d: 实际版本 %d", version, level, q.Version)
+			}
+			if got := decodeQR(t, q); got != content {
+				t.Errorf("version %d level %d: 解码内容不一致", version, level)
+			}
+		}
+	}
+}
+
+func TestQRRoundTripMasks(t *testing.T) {
+	for _, version := range []int{1, 7} {
+		for mask := 0; mask < 8; mask++ {
+			content := qrTestContent(version, QRLevelQ)
+			q, err := newQRCode(content, QRLevelQ, mask)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if q.Mask != mask {
+				t.Fatalf("掩码 %d, 期望 %d", q.Mask, mask)
+			}
+			if got := decodeQR(t, q); got != content {
+				t.Errorf("version %d mask %d: 解码内容不一致", version, mask)
+			}
+		}
+	}
+}
+
+// TestQRGolden 与 testdata 中的模块矩阵快照比对
+// 快照由本实现生成，并经 decodeQR 独立解码及 ISO 格式信息、版本信息校验，用于发现纠错码、掩码等改动引起的回归
+func TestQRGolden(t *testing.T) {
+	cases := []struct {
+		name    string
+		content string
+		level   QRLevel
+		mask    int
+		version int
+	}{
+		{"v1_L_mask3", "baofu", QRLevelL, 3, 1},
+		{"v3_M_auto", "weixin://wxpay/bizpayurl?pr=abc", QRLevelM, -1, 3},
+		{"v4_H_mask6", "https://qr.alipay.com/bax0123", QRLevelH, 6, 4},
+		{"v7_Q_mask0", strings.Repeat("宝付", 14), QRLevelQ, 0, 7},
+	}
+	for _, c := range cases {
+		q, err := newQRCode(c.content, c.level, c.mask)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if q.Version != c.version {
+			t.Fatalf("%s: 版本 %d, 期望 %d", c.name, q.Version, c.version)
+		}
+		if got := decodeQR(t, q); got != c.content {
+			t.Fatalf("%s: 解码内容不一致", c.name)
+		}
+
+		got := qrMatrixString(q)
+		path := filepath.Join("testdata", "qrcode", c.name+".txt")
+		if *updateGolden {
+			if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(path, []byte(got), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			continue
+		}
+		want, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != string(want) {
+			t.Errorf("%s: 模块矩阵与快照不一致\n%s", c.name, got)
+		}
+	}
+}
+
+func qrTestContent(version int, level QRLevel) string {
+	countBits := 8
+	if version >= 10 {
+		countBits = 16
+	}
+	n := (qrNumDataCodewords(version, level)*8 - 4 - countBits) / 8
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = byte(i*31 + version*7 + int(level))
+	}
+	return string(b)
+}
+
+func qrMatrixString(q *QRCode) string {
+	var sb strings.Builder
+	for y := 0; y < q.Size; y++ {
+		for x := 0; x < q.Size; x++ {
+			if q.Dark(x, y) {
+				sb.WriteByte('#')
+			} else {
+				sb.WriteByte('.')
+			}
+		}
+		sb.WriteByte('\n')
+	}
+	return sb.String()
+}
+
+func readQRFormat(m [][]bool, size int, secondary bool) int {
+	bits := 0
+	set := func(i, x, y int) {
+		if m[y][x] {
+			bits |= 1 << uint(i)
+		}
+	}
+	if secondary {
+		for i := 0; i < 8; i++ {
+			set(i, size-1-i, 8)
+		}
+		for i := 8; i < 15; i++ {
+			set(i, 8, size-15+i)
+		}
+		return bits
+	}
+	for i := 0; i <= 5; i++ {
+		set(i, 8, i)
+	}
+	set(6, 8, 7)
+	set(7, 8, 8)
+	set(8, 7, 8)
+	for i := 9; i < 15; i++ {
+		set(i, 14-i, 8)
+	}
+	return bits
+}
+
+func readQRVersion(m [][]bool, size int, bottomLeft bool) int {
+	bits := 0
+	for i := 0; i < 18; i++ {
+		a, b := size-11+i%3, i/3
+		x, y := a, b
+		if bottomLeft {
+			x, y = b, a
+		}
+		if m[y][x] {
+			bits |= 1 << uint(i)
+		}
+	}
+	return bits
+}
+
+// decodeQR 按 ISO/IEC 18004 独立解码字节模式二维码，校验格式信息、版本信息、纠错码及填充码字
+func decodeQR(t *testing.T, q *QRCode) string {
+	t.Helper()
+	size := q.Size
+	version := (size - 17) / 4
+	m := make([][]bool, size)
+	for y := range m {
+		m[y] = make([]bool, size)
+		for x := range m[y] {
+			m[y][x] = q.Dark(x, y)
+		}
+	}
+
+	// 功能图形区域
+	fn := make([][]bool, size)
+	for y := range fn {
+		fn[y] = make([]bool, size)
+	}
+	mark := func(x0, y0, x1, y1 int) {
+		for y := y0; y <= y1; y++ {
+			for x := x0; x <= x1; x++ {
+				fn[y][x] = true
+			}
+		}
+	}
+	mark(0, 0, 8, 8)
+	mark(size-8, 0, size-1, 8)
+	mark(0, size-8, 8, size-1)
+	mark(6, 0, 6, size-1)
+	mark(0, 6, size-1, 6)
+	align := q.alignmentPatternPositions()
+	for i, cx := range align {
+		for j, cy := range align {
+			if (i == 0 && j == 0) || (i == 0 && j == len(align)-1) || (i == len(align)-1 && j == 0) {
+				continue
+			}
+			mark(cx-2, cy-2, cx+2, cy+2)
+		}
+	}
+	if version >= 7 {
+		mark(size-11, 0, size-9, 5)
+		mark(0, size-11, 5, size-9)
+		top, bottom := readQRVersion(m, size, false), readQRVersion(m, size, true)
+		if top != bottom {
+			t.Fatalf("两处版本信息不一致 %018b %018b", top, bottom)
+		}
+		if want, ok := qrVersionTable[version]; ok && top != parseBits(t, want) {
+			t.Fatalf("版本信息 %018b, 期望 %s", top, want)
+		}
+	}
+
+	// 格式信息
+	format := readQRFormat(m, size, false)
+	if format != readQRFormat(m, size, true) {
+		t.Fatalf("两处格式信息不一致")
+	}
+	level, mask := QRLevel(-1), -1
+	for l, masks := range qrFormatTable {
+		for i, s := range masks {
+			if parseBits(t, s) == format {
+				level, mask = l, i
+			}
+		}
+	}
+	if mask < 0 {
+		t.Fatalf("无效的格式信息 %015b", format)
+	}
+	if level != q.Level || mask != q.Mask {
+		t.Fatalf("格式信息 level %d mask %d, 期望 level %d mask %d", level, mask, q.Level, q.Mask)
+	}
+	if !m[size-8][8] {
+		t.Fatalf("缺少固定深色模块")
+	}
+
+	masked := func(x, y int) bool {
+		i, j := y, x
+		switch mask {
+		case 0:
+			return (i+j)%2 == 0
+		case 1:
+			return i%2 == 0
+		case 2:
+			return j%3 == 0
+		case 3:
+			return (i+j)%3 == 0
+		case 4:
+			return (i/2+j/3)%2 == 0
+		case 5:
+			return (i*j)%2+(i*j)%3 == 0
+		case 6:
+			return ((i*j)%2+(i*j)%3)%2 == 0
+		default:
+			return ((i+j)%2+(i*j)%3)%2 == 0
+		}
+	}
+
+	// 之字形读取码字
+	var bits []bool
+	upward := true
+	for x := size - 1; x > 0; x -= 2 {
+		if x == 6 {
+			x--
+		}
+		for k := 0; k < size; k++ {
+			y := k
+			if upward {
+				y = size - 1 - k
+			}
+			for _, xx := range []int{x, x - 1} {
+				if !fn[y][xx] {
+					bits = append(bits, m[y][xx] != masked(xx, y))
+				}
+			}
+		}
+		upward = !upward
+	}
+	total := len(bits) / 8
+	raw := make([]byte, total)
+	for i := 0; i < total*8; i++ {
+		if bits[i] {
+			raw[i/8] |= 1 << uint(7-i%8)
+		}
+	}
+
+	// 解交织并校验纠错码
+	numBlocks := qrNumErrorCorrectionBlocks[level][version]
+	eccLen := qrEccCodewordsPerBlock[level][version]
+	shortLen := total / numBlocks
+	numShort := numBlocks - total%numBlocks
+	blocks := make([][]byte, numBlocks)
+	dataLens := make([]int, numBlocks)
+	for b := range blocks {
+		dataLens[b] = shortLen - eccLen
+		if b >= numShort {
+			dataLens[b]++
+		}
+	}
+	k := 0
+	for i := 0; i <= shortLen-eccLen; i++ {
+		for b := range blocks {
+			if i < dataLens[b] {
+				blocks[b] = append(blocks[b], raw[k])
+				k++
+			}
+		}
+	}
+	for i := 0; i < eccLen; i++ {
+		for b := range blocks {
+			blocks[b] = append(blocks[b], raw[k])
+			k++
+		}
+	}
+	var data []byte
+	for b, block := range blocks {
+		for j := 0; j < eccLen; j++ {
+			if s := gfPolyEval(block, gfPow(j)); s != 0 {
+				t.Fatalf("第 %d 个分组伴随式 S%d=%d 不为0", b, j, s)
+			}
+		}
+		data = append(data, block[:dataLens[b]]...)
+	}
+
+	// 解析字节模式数据
+	pos := 0
+	read := func(n int) int {
+		v := 0
+		for i := 0; i < n; i++ {
+			v = v<<1 | int(data[pos/8]>>uint(7-pos%8)&1)
+			pos++
+		}
+		return v
+	}
+	if mode := read(4); mode != 0x4 {
+		t.Fatalf("模式指示符 %04b 不是字节模式", mode)
+	}
+	countBits := 8
+	if version >= 10 {
+		countBits = 16
+	}
+	n := read(countBits)
+	content := make([]byte, n)
+	for i := range content {
+		content[i] = byte(read(8))
+	}
+	for i := 0; i < 4 && pos < len(data)*8; i++ {
+		if read(1) != 0 {
+			t.Fatalf("终止符不为0")
+		}
+	}
+	for pos%8 != 0 {
+		if read(1) != 0 {
+			t.Fatalf("补齐位不为0")
+		}
+	}
+	for pad := byte(0xEC); pos < len(data)*8; pad ^= 0xEC ^ 0x11 {
+		if got := byte(read(8)); got != pad {
+			t.Fatalf("填充码字 %#x, 期望 %#x", got, pad)
+		}
+	}
+	return string(content)
+}
+
+// gfPow 计算 GF(256)（本原多项式 0x11D）中 α 的 n 次幂
+func gfPow(n int) byte {
+	v := 1
+	for i := 0; i < n; i++ {
+		v <<= 1
+		if v&0x100 != 0 {
+			v ^= 0x11D
+		}
+	}
+	return byte(v)
+}
+
+func gfMul(a, b byte) byte {
+	var p byte
+	for b != 0 {
+		if b&1 != 0 {
+			p ^= a
+		}
+		hi := a & 0x80
+		a <<= 1
+		if hi != 0 {
+			a ^= 0x1D
+		}
+		b >>= 1
+	}
+	return p
+}
+
+// gfPolyEval 以秦九韶算法计算码字多项式（首个码字为最高次项）在 x 处的值
+func gfPolyEval(poly []byte, x byte) byte {
+	var v byte
+	for _, c := range poly {
+		v = gfMul(v, x) ^ c
+	}
+	return v
+}